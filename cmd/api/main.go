@@ -9,10 +9,12 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/razaq-himawan/chat-app-api/internal/database"
 	"github.com/razaq-himawan/chat-app-api/internal/server"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, wsServer *websocket.WebSocketServer, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -22,6 +24,15 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 
 	log.Println("shutting down gracefully, press Ctrl+C again to force")
 
+	// Drain the hub first: HTTP shutdown does not track hijacked WebSocket
+	// connections and would wait on open event streams until its deadline,
+	// so every client is told to reconnect, which also ends those streams.
+	hubCtx, cancelHub := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelHub()
+	if err := wsServer.Shutdown(hubCtx); err != nil {
+		log.Printf("WebSocket server forced to shutdown with error: %v", err)
+	}
+
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -36,15 +47,28 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	done <- true
 }
 
+// newPubSub picks the backend the hub uses to reach the other instances.
+func newPubSub(config websocket.Config) websocket.PubSub {
+	if config.PubSub == websocket.PubSubPostgres {
+		return websocket.NewPostgresPubSub(database.New().GetDB(), config.PubSubChannel)
+	}
+	return websocket.NewMemoryPubSub()
+}
+
 func main() {
 
-	server := server.NewServer()
+	// The WebSocket hub lives as long as the process, not a single request
+	wsConfig := websocket.ConfigFromEnv()
+	wsServer := websocket.NewWebSocketServer(wsConfig, newPubSub(wsConfig))
+	go wsServer.Start(context.Background())
+
+	server := server.NewServer(wsServer)
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, wsServer, done)
 
 	err := server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
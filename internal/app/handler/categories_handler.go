@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type CategoryHandler struct {
+	categoryService model.CategoryService
+	memberService   model.MemberService
+	wsServer        *websocket.WebSocketServer
+}
+
+func NewCategoryHandler(categoryService model.CategoryService, memberService model.MemberService, wsServer *websocket.WebSocketServer) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService, memberService: memberService, wsServer: wsServer}
+}
+
+func (h *CategoryHandler) HandleCreateCategory(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.CreateCategoryPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	category, err := h.categoryService.CreateCategory(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToServer(h.wsServer, h.memberService, userID, category.ServerID, model.EventCategoryCreated, category)
+
+	utils.WriteJSON(w, http.StatusCreated, category)
+}
+
+func (h *CategoryHandler) HandleUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.UpdateCategoryPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(userID, categoryID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToServer(h.wsServer, h.memberService, userID, category.ServerID, model.EventCategoryUpdated, category)
+
+	utils.WriteJSON(w, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) HandleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	category, err := h.categoryService.DeleteCategory(userID, categoryID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	members, err := h.memberService.GetServerMembers(userID, category.ServerID)
+	if err != nil {
+		log.Printf("failed to fetch server members: %v", err)
+	}
+
+	publishToMembers(h.wsServer, members, model.EventCategoryDeleted, model.WSCategoryDeletedPayload{
+		CategoryID: category.ID,
+		ServerID:   category.ServerID,
+	})
+	publishToViewers(h.wsServer, h.memberService, userID, category.ServerID, model.EventChannelUpdated, category.Channels)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "category deleted",
+	})
+}
+
+func (h *CategoryHandler) HandleReorderCategories(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.ReorderCategoriesPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	categories, err := h.categoryService.ReorderCategories(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	members, err := h.memberService.GetServerMembers(userID, serverID)
+	if err != nil {
+		log.Printf("failed to fetch server members: %v", err)
+	}
+
+	for _, category := range categories {
+		publishToMembers(h.wsServer, members, model.EventCategoryUpdated, category)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, categories)
+}
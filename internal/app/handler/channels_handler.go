@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type ChannelHandler struct {
+	channelService model.ChannelService
+	memberService  model.MemberService
+	wsServer       *websocket.WebSocketServer
+}
+
+func NewChannelHandler(channelService model.ChannelService, memberService model.MemberService, wsServer *websocket.WebSocketServer) *ChannelHandler {
+	return &ChannelHandler{channelService: channelService, memberService: memberService, wsServer: wsServer}
+}
+
+func (h *ChannelHandler) HandleGetServerChannels(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	channels, err := h.channelService.GetServerChannels(userID, serverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, channels)
+}
+
+func (h *ChannelHandler) HandleCreateChannel(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.CreateChannelPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	channel, err := h.channelService.CreateChannel(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToViewers(h.wsServer, h.memberService, userID, channel.ServerID, model.EventChannelCreated, []model.Channel{*channel})
+
+	utils.WriteJSON(w, http.StatusCreated, channel)
+}
+
+func (h *ChannelHandler) HandleUpdateChannel(w http.ResponseWriter, r *http.Request) {
+	channelID := chi.URLParam(r, "channelID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.UpdateChannelPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	channel, err := h.channelService.UpdateChannel(userID, channelID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToViewers(h.wsServer, h.memberService, userID, channel.ServerID, model.EventChannelUpdated, []model.Channel{*channel})
+
+	utils.WriteJSON(w, http.StatusOK, channel)
+}
+
+func (h *ChannelHandler) HandleDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	channelID := chi.URLParam(r, "channelID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	channel, viewers, err := h.channelService.DeleteChannel(userID, channelID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	members, err := h.memberService.GetServerMembers(userID, channel.ServerID)
+	if err != nil {
+		log.Printf("failed to fetch server members: %v", err)
+	}
+
+	revokeSubscriptions(h.wsServer, channel.ID, members)
+	publishToMembers(h.wsServer, viewers, model.EventChannelDeleted, model.WSChannelDeletedPayload{
+		ChannelID: channel.ID,
+		ServerID:  channel.ServerID,
+	})
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "channel deleted",
+	})
+}
+
+func (h *ChannelHandler) HandleReorderChannels(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.ReorderChannelsPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	channels, err := h.channelService.ReorderChannels(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToViewers(h.wsServer, h.memberService, userID, serverID, model.EventChannelUpdated, channels)
+
+	utils.WriteJSON(w, http.StatusOK, channels)
+}
+
+func (h *ChannelHandler) HandleMoveChannel(w http.ResponseWriter, r *http.Request) {
+	channelID := chi.URLParam(r, "channelID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.MoveChannelPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	channels, err := h.channelService.MoveChannel(userID, channelID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if len(channels) > 0 {
+		publishToViewers(h.wsServer, h.memberService, userID, channels[0].ServerID, model.EventChannelUpdated, channels)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, channels)
+}
+
+func (h *ChannelHandler) HandleGetChannelOverwrites(w http.ResponseWriter, r *http.Request) {
+	channelID := chi.URLParam(r, "channelID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	overwrites, err := h.channelService.GetChannelOverwrites(userID, channelID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, overwrites)
+}
+
+func (h *ChannelHandler) HandleSetChannelOverwrite(w http.ResponseWriter, r *http.Request) {
+	channelID := chi.URLParam(r, "channelID")
+	roleID := chi.URLParam(r, "roleID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.SetChannelOverwritePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	overwrite, hidden, err := h.channelService.SetChannelOverwrite(userID, channelID, roleID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	revokeSubscriptions(h.wsServer, channelID, hidden)
+
+	utils.WriteJSON(w, http.StatusOK, overwrite)
+}
+
+func (h *ChannelHandler) HandleDeleteChannelOverwrite(w http.ResponseWriter, r *http.Request) {
+	channelID := chi.URLParam(r, "channelID")
+	roleID := chi.URLParam(r, "roleID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	hidden, err := h.channelService.DeleteChannelOverwrite(userID, channelID, roleID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	revokeSubscriptions(h.wsServer, channelID, hidden)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "channel overwrite deleted",
+	})
+}
+
+// revokeSubscriptions drops the channel subscriptions of members who can no
+// longer view the channel, so that its messages stop reaching them.
+func revokeSubscriptions(wsServer *websocket.WebSocketServer, channelID string, members []model.Member) {
+	topic := websocket.ChannelTopic(channelID)
+	for _, member := range members {
+		if err := wsServer.UnsubscribeUser(member.UserID, topic); err != nil {
+			log.Printf("failed to revoke channel subscriptions: %v", err)
+		}
+	}
+}
+
+// publishToViewers sends a channel event for each channel to the members
+// who may view it, so that hidden channels stay hidden. The unread counts
+// belong to the user who made the change and are left out.
+func publishToViewers(wsServer *websocket.WebSocketServer, memberService model.MemberService, userID, serverID string, eventType model.WSEventType, channels []model.Channel) {
+	viewers, err := memberService.GetChannelViewers(userID, serverID, channels)
+	if err != nil {
+		log.Printf("failed to publish %s: %v", eventType, err)
+		return
+	}
+
+	for _, channel := range channels {
+		channel.UnreadCount, channel.MentionCount = 0, 0
+		publishToMembers(wsServer, viewers[channel.ID], eventType, channel)
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type ConversationHandler struct {
+	conversationService model.ConversationService
+	wsServer            *websocket.WebSocketServer
+}
+
+func NewConversationHandler(conversationService model.ConversationService, wsServer *websocket.WebSocketServer) *ConversationHandler {
+	return &ConversationHandler{conversationService: conversationService, wsServer: wsServer}
+}
+
+func (h *ConversationHandler) HandleGetOrCreateConversation(w http.ResponseWriter, r *http.Request) {
+	var payload model.CreateConversationPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	conversation, err := h.conversationService.GetOrCreateConversation(userID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, conversation)
+}
+
+func (h *ConversationHandler) HandleGetConversations(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	conversations, err := h.conversationService.GetUserConversations(userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, conversations)
+}
+
+func (h *ConversationHandler) HandleGetConversation(w http.ResponseWriter, r *http.Request) {
+	conversationID := chi.URLParam(r, "conversationID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	conversation, err := h.conversationService.GetConversation(userID, conversationID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, conversation)
+}
+
+func (h *ConversationHandler) HandleCreateGroupConversation(w http.ResponseWriter, r *http.Request) {
+	var payload model.CreateGroupConversationPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	conversation, err := h.conversationService.CreateGroupConversation(userID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.wsServer.PublishToUsers(conversation.ParticipantIDs, model.EventConversationCreated, conversation); err != nil {
+		log.Printf("failed to publish conversation creation: %v", err)
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, conversation)
+}
+
+func (h *ConversationHandler) HandleRenameConversation(w http.ResponseWriter, r *http.Request) {
+	conversationID := chi.URLParam(r, "conversationID")
+
+	var payload model.RenameConversationPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	conversation, message, err := h.conversationService.RenameGroupConversation(userID, conversationID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.publishConversationChange(conversation, message)
+
+	utils.WriteJSON(w, http.StatusOK, conversation)
+}
+
+func (h *ConversationHandler) HandleAddParticipants(w http.ResponseWriter, r *http.Request) {
+	conversationID := chi.URLParam(r, "conversationID")
+
+	var payload model.AddParticipantsPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	conversation, added, message, err := h.conversationService.AddParticipants(userID, conversationID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.wsServer.PublishToUsers(added, model.EventConversationCreated, conversation); err != nil {
+		log.Printf("failed to publish conversation creation: %v", err)
+	}
+	h.publishConversationChange(conversation, message)
+
+	utils.WriteJSON(w, http.StatusOK, conversation)
+}
+
+func (h *ConversationHandler) HandleRemoveParticipant(w http.ResponseWriter, r *http.Request) {
+	conversationID := chi.URLParam(r, "conversationID")
+	targetUserID := chi.URLParam(r, "userID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	h.removeParticipant(w, userID, conversationID, targetUserID)
+}
+
+func (h *ConversationHandler) HandleLeaveConversation(w http.ResponseWriter, r *http.Request) {
+	conversationID := chi.URLParam(r, "conversationID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	h.removeParticipant(w, userID, conversationID, userID)
+}
+
+func (h *ConversationHandler) removeParticipant(w http.ResponseWriter, userID, conversationID, targetUserID string) {
+	conversation, message, err := h.conversationService.RemoveParticipant(userID, conversationID, targetUserID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.wsServer.UnsubscribeUser(targetUserID, websocket.ConversationTopic(conversation.ID)); err != nil {
+		log.Printf("failed to revoke conversation subscriptions: %v", err)
+	}
+	if err := h.wsServer.PublishToUser(targetUserID, model.EventConversationRemoved, conversation); err != nil {
+		log.Printf("failed to publish conversation removal: %v", err)
+	}
+	h.publishConversationChange(conversation, message)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "participant removed",
+	})
+}
+
+// publishConversationChange sends the updated conversation and the system
+// message recording the change to every subscriber of the conversation.
+func (h *ConversationHandler) publishConversationChange(conversation *model.Conversation, message *model.Message) {
+	if message == nil {
+		return
+	}
+
+	topic := websocket.ConversationTopic(conversation.ID)
+	if err := h.wsServer.Publish(topic, model.EventConversationUpdated, conversation); err != nil {
+		log.Printf("failed to publish conversation update: %v", err)
+	}
+	if err := h.wsServer.Publish(topic, model.EventMessageCreated, message); err != nil {
+		log.Printf("failed to publish system message: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+// writeServiceError maps the model sentinel errors onto HTTP status codes.
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrForbidden):
+		utils.WriteError(w, http.StatusForbidden, err)
+	case errors.Is(err, model.ErrNotFound):
+		utils.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, model.ErrInvalid):
+		utils.WriteError(w, http.StatusBadRequest, err)
+	case errors.Is(err, model.ErrConflict):
+		utils.WriteError(w, http.StatusConflict, err)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+// maxOpSize matches the default read limit of a WebSocket connection.
+const maxOpSize = 32768
+
+// EventStreamHandler serves the Server-Sent Events fallback of /ws, for
+// clients behind proxies that break WebSocket upgrades.
+type EventStreamHandler struct {
+	wsServer *websocket.WebSocketServer
+}
+
+func NewEventStreamHandler(wsServer *websocket.WebSocketServer) *EventStreamHandler {
+	return &EventStreamHandler{wsServer: wsServer}
+}
+
+// HandleEventStream streams the same envelopes a WebSocket session receives.
+// The stream is read-only, ops are posted to HandleEventStreamOp with the
+// session id from the hello frame.
+func (h *EventStreamHandler) HandleEventStream(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	sessionID, lastSeq := streamResumeParams(r)
+
+	ctx, stop := context.WithCancel(r.Context())
+	defer stop()
+
+	client := h.wsServer.NewStreamClient(utils.RandomID(), userID, stop)
+
+	startSeq := int64(0)
+	if h.wsServer.Connect(client, sessionID, lastSeq) {
+		startSeq = lastSeq
+	}
+	defer h.wsServer.Disconnect(client)
+
+	if err := h.wsServer.Stream(ctx, client, w, startSeq); err != nil {
+		log.Printf("Error streaming events to session %s of user %s: %v", client.ID, userID, err)
+	}
+}
+
+// HandleEventStreamOp runs a client op on an event stream session, as if it
+// was sent over /ws. The ack or error is delivered on the stream.
+func (h *EventStreamHandler) HandleEventStreamOp(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	client, ok := h.wsServer.Session(userID, sessionID)
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("session not found"))
+		return
+	}
+
+	frame, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOpSize))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to read op: %v", err))
+		return
+	}
+
+	h.wsServer.Dispatch(r.Context(), client, frame)
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]string{
+		"message": "op accepted",
+	})
+}
+
+// streamResumeParams prefers the Last-Event-ID header an EventSource sends
+// when it reconnects on its own, made of the session id and the last seq, over
+// the session_id and seq query parameters.
+func streamResumeParams(r *http.Request) (string, int64) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		return resumeParams(r)
+	}
+
+	sessionID, seq, _ := strings.Cut(lastEventID, ":")
+
+	lastSeq, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil {
+		lastSeq = -1
+	}
+
+	return sessionID, lastSeq
+}
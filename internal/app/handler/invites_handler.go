@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type InviteHandler struct {
+	inviteService model.InviteService
+}
+
+func NewInviteHandler(inviteService model.InviteService) *InviteHandler {
+	return &InviteHandler{inviteService: inviteService}
+}
+
+func (h *InviteHandler) HandleCreateInvite(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.CreateInvitePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	invite, err := h.inviteService.CreateInvite(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, invite)
+}
+
+func (h *InviteHandler) HandleGetServerInvites(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	invites, err := h.inviteService.GetServerInvites(userID, serverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, invites)
+}
+
+func (h *InviteHandler) HandleRevokeInvite(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	code := chi.URLParam(r, "code")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	if _, err := h.inviteService.RevokeInvite(userID, serverID, code); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "invite revoked",
+	})
+}
+
+func (h *InviteHandler) HandleRegenerateDefaultInvite(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	invite, err := h.inviteService.RegenerateDefaultInvite(userID, serverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, invite)
+}
+
+func (h *InviteHandler) HandlePreviewInvite(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+
+	preview, err := h.inviteService.PreviewInvite(code)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, preview)
+}
+
+func (h *InviteHandler) HandleAcceptInvite(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	member, err := h.inviteService.AcceptInvite(userID, code)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, member)
+}
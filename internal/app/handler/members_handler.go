@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type MemberHandler struct {
+	memberService model.MemberService
+	wsServer      *websocket.WebSocketServer
+}
+
+func NewMemberHandler(memberService model.MemberService, wsServer *websocket.WebSocketServer) *MemberHandler {
+	return &MemberHandler{memberService: memberService, wsServer: wsServer}
+}
+
+func (h *MemberHandler) HandleLeaveServer(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	member, err := h.memberService.LeaveServer(userID, serverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.revokeAccess(member, model.RemovalLeave)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "left server",
+	})
+}
+
+func (h *MemberHandler) HandleKickMember(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	memberID := chi.URLParam(r, "memberID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	member, err := h.memberService.KickMember(userID, serverID, memberID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.revokeAccess(member, model.RemovalKick)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "member kicked",
+	})
+}
+
+func (h *MemberHandler) HandleBanMember(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	memberID := chi.URLParam(r, "memberID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	member, err := h.memberService.BanMember(userID, serverID, memberID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.revokeAccess(member, model.RemovalBan)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "member banned",
+	})
+}
+
+// revokeAccess drops the removed member's live subscriptions to the server's
+// channels and tells their connections why.
+func (h *MemberHandler) revokeAccess(member *model.Member, reason model.WSRemovalReason) {
+	if err := h.wsServer.UnsubscribeUserFromServer(member.UserID, member.ServerID); err != nil {
+		log.Printf("failed to revoke server subscriptions: %v", err)
+	}
+
+	err := h.wsServer.PublishToUser(member.UserID, model.EventMemberRemoved, model.WSMemberRemovedPayload{
+		ServerID: member.ServerID,
+		MemberID: member.ID,
+		UserID:   member.UserID,
+		Reason:   reason,
+	})
+	if err != nil {
+		log.Printf("failed to publish member removal: %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type MessageHandler struct {
+	messageService model.MessageService
+	wsServer       *websocket.WebSocketServer
+}
+
+func NewMessageHandler(messageService model.MessageService, wsServer *websocket.WebSocketServer) *MessageHandler {
+	return &MessageHandler{messageService: messageService, wsServer: wsServer}
+}
+
+func (h *MessageHandler) HandleCreateChannelMessage(w http.ResponseWriter, r *http.Request) {
+	h.handleCreateMessage(w, r, model.CreateMessagePayload{ChannelID: chi.URLParam(r, "channelID")})
+}
+
+func (h *MessageHandler) HandleCreateConversationMessage(w http.ResponseWriter, r *http.Request) {
+	h.handleCreateMessage(w, r, model.CreateMessagePayload{ConversationID: chi.URLParam(r, "conversationID")})
+}
+
+// handleCreateMessage is the REST counterpart of the message_create op, for
+// clients without a WebSocket connection. target holds the channel or
+// conversation from the URL. A retry with the same nonce returns the message
+// the first send created.
+func (h *MessageHandler) handleCreateMessage(w http.ResponseWriter, r *http.Request, target model.CreateMessagePayload) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.CreateMessagePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	payload.ChannelID = target.ChannelID
+	payload.ConversationID = target.ConversationID
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	message, messageID, err := createMessage(h.wsServer, h.messageService, userID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if message == nil {
+		message, err = h.messageService.GetMessageByID(messageID)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		message.Nonce = payload.Nonce
+
+		utils.WriteJSON(w, http.StatusOK, message)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, message)
+}
+
+func (h *MessageHandler) HandleGetChannelMessages(w http.ResponseWriter, r *http.Request) {
+	channelID := chi.URLParam(r, "channelID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	pageParams, err := parseMessagePageParams(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.messageService.GetChannelMessages(userID, channelID, pageParams)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, page)
+}
+
+func (h *MessageHandler) HandleGetConversationMessages(w http.ResponseWriter, r *http.Request) {
+	conversationID := chi.URLParam(r, "conversationID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	pageParams, err := parseMessagePageParams(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.messageService.GetConversationMessages(userID, conversationID, pageParams)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, page)
+}
+
+func (h *MessageHandler) HandleUpdateMessage(w http.ResponseWriter, r *http.Request) {
+	messageID := chi.URLParam(r, "messageID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.UpdateMessagePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	message, err := h.messageService.UpdateMessage(userID, messageID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := publishMessageUpdated(h.wsServer, message); err != nil {
+		log.Printf("failed to publish message update: %v", err)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, message)
+}
+
+func (h *MessageHandler) HandleDeleteMessage(w http.ResponseWriter, r *http.Request) {
+	messageID := chi.URLParam(r, "messageID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	message, err := h.messageService.DeleteMessage(userID, messageID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := publishMessageDeleted(h.wsServer, message); err != nil {
+		log.Printf("failed to publish message deletion: %v", err)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "message deleted",
+	})
+}
+
+func (h *MessageHandler) HandleAckMessage(w http.ResponseWriter, r *http.Request) {
+	messageID := chi.URLParam(r, "messageID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	state, err := h.messageService.AckMessage(userID, messageID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := publishReadState(h.wsServer, state); err != nil {
+		log.Printf("failed to publish read state: %v", err)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, state)
+}
+
+// createMessage persists a message, ends the sender's typing indicator and
+// publishes it to the topic. When the nonce was already used within the
+// nonce window it creates nothing and returns the id of the message the
+// first send created instead.
+// parseMessagePageParams reads the page parameters of a message history
+// request, whose cursor has to be a message id.
+func parseMessagePageParams(r *http.Request) (utils.PageParams, error) {
+	pageParams, err := utils.ParsePageParams(r)
+	if err != nil {
+		return pageParams, err
+	}
+
+	for _, cursor := range []string{pageParams.Before, pageParams.After, pageParams.Around} {
+		if cursor == "" {
+			continue
+		}
+		if err := utils.Validate.Var(cursor, "uuid"); err != nil {
+			return pageParams, fmt.Errorf("invalid cursor %q: must be a message id", cursor)
+		}
+	}
+
+	return pageParams, nil
+}
+
+func createMessage(wsServer *websocket.WebSocketServer, messageService model.MessageService, userID string, payload model.CreateMessagePayload) (*model.Message, string, error) {
+	topic, err := websocket.TopicFor(payload.ChannelID, payload.ConversationID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if payload.Nonce != "" {
+		messageID, claimed := wsServer.ClaimNonce(userID, payload.Nonce)
+		if !claimed {
+			if messageID == "" {
+				return nil, "", fmt.Errorf("%w: a message with this nonce is still being processed", model.ErrConflict)
+			}
+			return nil, messageID, nil
+		}
+	}
+
+	message, err := messageService.CreateMessage(userID, payload)
+	if err != nil {
+		if payload.Nonce != "" {
+			wsServer.ReleaseNonce(userID, payload.Nonce)
+		}
+		return nil, "", err
+	}
+	message.Nonce = payload.Nonce
+
+	if payload.Nonce != "" {
+		wsServer.ResolveNonce(userID, payload.Nonce, message.ID)
+	}
+
+	if err := wsServer.StopTyping(userID, topic); err != nil {
+		log.Printf("failed to publish typing stop: %v", err)
+	}
+
+	// The message is stored and its nonce resolved by now, so a retry would
+	// only get the id back. Failing the send here would hide it from the
+	// sender, so the failed publish is only logged.
+	if err := wsServer.Publish(topic, model.EventMessageCreated, message); err != nil {
+		log.Printf("failed to publish message creation: %v", err)
+	}
+
+	return message, "", nil
+}
+
+func publishMessageUpdated(wsServer *websocket.WebSocketServer, message *model.Message) error {
+	topic, err := websocket.TopicFor(message.ChannelID, message.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	return wsServer.Publish(topic, model.EventMessageUpdated, message)
+}
+
+func publishMessageDeleted(wsServer *websocket.WebSocketServer, message *model.Message) error {
+	topic, err := websocket.TopicFor(message.ChannelID, message.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	return wsServer.Publish(topic, model.EventMessageDeleted, model.WSMessageDeletedPayload{
+		MessageID:      message.ID,
+		ChannelID:      message.ChannelID,
+		ConversationID: message.ConversationID,
+	})
+}
+
+// publishReadState syncs a read state to every session of its user, so the
+// other devices clear their unread badges too.
+func publishReadState(wsServer *websocket.WebSocketServer, state *model.ReadState) error {
+	return wsServer.PublishToUser(state.UserID, model.EventReadStateUpdated, state)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"log"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+)
+
+// PresenceHandler turns the connection status reported by the websocket hub
+// into the status stored on profiles, and broadcasts it when it changes.
+type PresenceHandler struct {
+	presenceService model.PresenceService
+	wsServer        *websocket.WebSocketServer
+}
+
+// RegisterPresenceHooks subscribes a PresenceHandler to the connection
+// status changes of the hub. It has no routes of its own.
+func RegisterPresenceHooks(presenceService model.PresenceService, wsServer *websocket.WebSocketServer) {
+	h := &PresenceHandler{presenceService: presenceService, wsServer: wsServer}
+
+	// Statuses left over from a previous run are only known to be stale
+	// when this is the sole instance, otherwise they may belong to users
+	// connected to another one.
+	if !wsServer.Clustered() {
+		if err := presenceService.ResetStatuses(); err != nil {
+			log.Printf("failed to reset user statuses: %v", err)
+		}
+	}
+	wsServer.OnPresence(h.handleConnectionStatus)
+}
+
+func (h *PresenceHandler) handleConnectionStatus(userID string, status model.ProfileStatus) {
+	status, changed, err := h.presenceService.RefreshStatus(userID, status)
+	if err != nil {
+		log.Printf("failed to update presence of user %s: %v", userID, err)
+		return
+	}
+
+	if changed {
+		publishPresence(h.wsServer, h.presenceService, userID, status)
+	}
+}
+
+// publishPresence sends presence_update to the user's own sessions and to
+// everyone sharing a server or conversation with them.
+func publishPresence(wsServer *websocket.WebSocketServer, presenceService model.PresenceService, userID string, status model.ProfileStatus) {
+	audience, err := presenceService.GetPresenceAudience(userID)
+	if err != nil {
+		log.Printf("failed to fetch presence audience of user %s: %v", userID, err)
+		return
+	}
+
+	payload := model.WSPresencePayload{UserID: userID, Status: status}
+	if err := wsServer.PublishToUsers(append(audience, userID), model.EventPresenceUpdate, payload); err != nil {
+		log.Printf("failed to publish presence update: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type RoleHandler struct {
+	roleService   model.RoleService
+	memberService model.MemberService
+	wsServer      *websocket.WebSocketServer
+}
+
+func NewRoleHandler(roleService model.RoleService, memberService model.MemberService, wsServer *websocket.WebSocketServer) *RoleHandler {
+	return &RoleHandler{roleService: roleService, memberService: memberService, wsServer: wsServer}
+}
+
+func (h *RoleHandler) HandleGetServerRoles(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	roles, err := h.roleService.GetServerRoles(userID, serverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) HandleCreateRole(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.CreateRolePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	role, err := h.roleService.CreateRole(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToServer(h.wsServer, h.memberService, userID, serverID, model.EventRoleCreated, role)
+
+	utils.WriteJSON(w, http.StatusCreated, role)
+}
+
+func (h *RoleHandler) HandleUpdateRole(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	roleID := chi.URLParam(r, "roleID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.UpdateRolePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(userID, serverID, roleID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToServer(h.wsServer, h.memberService, userID, serverID, model.EventRoleUpdated, role)
+	h.revokeHiddenChannels(userID, serverID)
+
+	utils.WriteJSON(w, http.StatusOK, role)
+}
+
+func (h *RoleHandler) HandleDeleteRole(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	roleID := chi.URLParam(r, "roleID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	role, err := h.roleService.DeleteRole(userID, serverID, roleID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToServer(h.wsServer, h.memberService, userID, serverID, model.EventRoleDeleted, model.WSRoleDeletedPayload{
+		RoleID:   role.ID,
+		ServerID: role.ServerID,
+	})
+	h.revokeHiddenChannels(userID, serverID)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "role deleted",
+	})
+}
+
+func (h *RoleHandler) HandleReorderRoles(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.ReorderRolesPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	roles, err := h.roleService.ReorderRoles(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	members, err := h.memberService.GetServerMembers(userID, serverID)
+	if err != nil {
+		log.Printf("failed to fetch server members: %v", err)
+	}
+
+	for _, role := range roles {
+		publishToMembers(h.wsServer, members, model.EventRoleUpdated, role)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) HandleAddMemberRole(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	memberID := chi.URLParam(r, "memberID")
+	roleID := chi.URLParam(r, "roleID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	member, err := h.roleService.AddMemberRole(userID, serverID, memberID, roleID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToServer(h.wsServer, h.memberService, userID, serverID, model.EventMemberUpdated, member)
+	h.revokeHiddenChannels(userID, serverID)
+
+	utils.WriteJSON(w, http.StatusOK, member)
+}
+
+func (h *RoleHandler) HandleRemoveMemberRole(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	memberID := chi.URLParam(r, "memberID")
+	roleID := chi.URLParam(r, "roleID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	member, err := h.roleService.RemoveMemberRole(userID, serverID, memberID, roleID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	publishToServer(h.wsServer, h.memberService, userID, serverID, model.EventMemberUpdated, member)
+	h.revokeHiddenChannels(userID, serverID)
+
+	utils.WriteJSON(w, http.StatusOK, member)
+}
+
+// revokeHiddenChannels drops the subscriptions members hold to channels they
+// may no longer view, after a change to roles that can take the permission
+// away directly or through the overwrites of the channels.
+func (h *RoleHandler) revokeHiddenChannels(userID, serverID string) {
+	hidden, err := h.memberService.GetHiddenChannels(userID, serverID)
+	if err != nil {
+		log.Printf("failed to revoke channel subscriptions: %v", err)
+		return
+	}
+
+	for channelID, members := range hidden {
+		revokeSubscriptions(h.wsServer, channelID, members)
+	}
+}
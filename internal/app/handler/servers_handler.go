@@ -5,18 +5,21 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/razaq-himawan/chat-app-api/internal/app/model"
 	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
 	"github.com/razaq-himawan/chat-app-api/utils"
 )
 
 type ServerHandler struct {
 	serverService model.ServerService
+	wsServer      *websocket.WebSocketServer
 }
 
-func NewServerHandler(serverService model.ServerService) *ServerHandler {
-	return &ServerHandler{serverService: serverService}
+func NewServerHandler(serverService model.ServerService, wsServer *websocket.WebSocketServer) *ServerHandler {
+	return &ServerHandler{serverService: serverService, wsServer: wsServer}
 }
 
 func (h *ServerHandler) CreateServer(w http.ResponseWriter, r *http.Request) {
@@ -44,3 +47,106 @@ func (h *ServerHandler) CreateServer(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteJSON(w, http.StatusCreated, createdServer)
 }
+
+func (h *ServerHandler) HandleGetServers(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	servers, err := h.serverService.GetUserServers(userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, servers)
+}
+
+func (h *ServerHandler) HandleGetServer(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	server, err := h.serverService.GetServer(userID, serverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, server)
+}
+
+func (h *ServerHandler) HandleUpdateServer(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload model.UpdateServerPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		return
+	}
+
+	server, err := h.serverService.UpdateServer(userID, serverID, payload)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	updated := *server
+	updated.Members = nil
+	publishToMembers(h.wsServer, server.Members, model.EventServerUpdated, updated)
+
+	utils.WriteJSON(w, http.StatusOK, server)
+}
+
+func (h *ServerHandler) HandleDeleteServer(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "serverID")
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	server, err := h.serverService.DeleteServer(userID, serverID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	for _, member := range server.Members {
+		if err := h.wsServer.UnsubscribeUserFromServer(member.UserID, server.ID); err != nil {
+			log.Printf("failed to revoke server subscriptions: %v", err)
+		}
+	}
+	publishToMembers(h.wsServer, server.Members, model.EventServerDeleted, model.WSServerDeletedPayload{
+		ServerID: server.ID,
+	})
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "server deleted",
+	})
+}
+
+// publishToMembers sends a server event to every session of each member, as
+// there is no topic every member of a server subscribes to.
+func publishToMembers(wsServer *websocket.WebSocketServer, members []model.Member, eventType model.WSEventType, data any) {
+	userIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+
+	if err := wsServer.PublishToUsers(userIDs, eventType, data); err != nil {
+		log.Printf("failed to publish %s: %v", eventType, err)
+	}
+}
+
+// publishToServer sends a server event to every member of the server.
+// Failing to notify them does not fail the request, which already succeeded.
+func publishToServer(wsServer *websocket.WebSocketServer, memberService model.MemberService, userID, serverID string, eventType model.WSEventType, data any) {
+	members, err := memberService.GetServerMembers(userID, serverID)
+	if err != nil {
+		log.Printf("failed to publish %s: %v", eventType, err)
+		return
+	}
+
+	publishToMembers(wsServer, members, eventType, data)
+}
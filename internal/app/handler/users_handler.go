@@ -2,21 +2,25 @@ package handler
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
 	"github.com/razaq-himawan/chat-app-api/utils"
 )
 
 type UserHandler struct {
-	userService model.UserService
+	userService     model.UserService
+	presenceService model.PresenceService
+	wsServer        *websocket.WebSocketServer
 }
 
-func NewUserHandler(userService model.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService model.UserService, presenceService model.PresenceService, wsServer *websocket.WebSocketServer) *UserHandler {
+	return &UserHandler{userService: userService, presenceService: presenceService, wsServer: wsServer}
 }
 
 func (h *UserHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
@@ -132,6 +136,16 @@ func (h *UserHandler) HandleUpdateUserProfile(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	status, changed, err := h.presenceService.RefreshStatus(userID, h.wsServer.ConnectionStatus(userID))
+	if err != nil {
+		log.Printf("failed to refresh presence of user %s: %v", userID, err)
+	} else {
+		up.Status = status
+		if changed {
+			publishPresence(h.wsServer, h.presenceService, userID, status)
+		}
+	}
+
 	utils.WriteJSON(w, http.StatusOK, up)
 }
 
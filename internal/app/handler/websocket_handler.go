@@ -1,79 +1,242 @@
-package handler
-
-// TODO: MAKE THIS HANDLER DYNAMIC
-
-import (
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
-	"time"
-
-	ws "github.com/coder/websocket"
-	"github.com/razaq-himawan/chat-app-api/internal/app/model"
-	"github.com/razaq-himawan/chat-app-api/internal/auth"
-	"github.com/razaq-himawan/chat-app-api/internal/websocket"
-	"github.com/razaq-himawan/chat-app-api/utils"
-)
-
-func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	tokenString := utils.GetTokenFromCookie(r)
-
-	userID, err := auth.GetUserIDFromToken(tokenString)
-	if err != nil {
-		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
-		return
-	}
-
-	conn, err := ws.Accept(w, r, nil)
-	if err != nil {
-		log.Println("Failed to accept WebSocket connection:", err)
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to open WebSocket connection"))
-		return
-	}
-
-	wsServer := websocket.GetWebSocketServer()
-
-	go wsServer.Start(ctx)
-
-	client := &model.WebSocketUser{
-		UserID:         userID,
-		Conn:           conn,
-		Type:           model.DM,
-		ConversationID: "conv1",
-		IsOnline:       true,
-	}
-	wsServer.Register <- client
-
-	defer func() {
-		wsServer.Unregister <- client
-		if err := conn.Close(ws.StatusNormalClosure, "Connection closed"); err != nil {
-			log.Println("Error closing WebSocket connection:", err)
-		}
-	}()
-
-	for {
-		_, messageBytes, err := conn.Read(ctx)
-		if err != nil {
-			log.Println("Error reading message:", err)
-			break
-		}
-
-		var message model.Message
-		if err := json.Unmarshal(messageBytes, &message); err != nil {
-			log.Println("Error unmarshaling message:", err)
-			continue
-		}
-
-		wsServer.Broadcast <- &model.Message{
-			ID:             "test",
-			Content:        message.Content,
-			MemberID:       client.UserID,
-			ConversationID: message.ConversationID,
-			CreatedAt:      time.Now(),
-			UpdatedAt:      time.Now(),
-		}
-	}
-}
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	ws "github.com/coder/websocket"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/auth"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type WebSocketHandler struct {
+	wsServer            *websocket.WebSocketServer
+	messageService      model.MessageService
+	conversationService model.ConversationService
+	channelService      model.ChannelService
+}
+
+func NewWebSocketHandler(wsServer *websocket.WebSocketServer, messageService model.MessageService, conversationService model.ConversationService, channelService model.ChannelService) *WebSocketHandler {
+	h := &WebSocketHandler{
+		wsServer:            wsServer,
+		messageService:      messageService,
+		conversationService: conversationService,
+		channelService:      channelService,
+	}
+
+	wsServer.HandleOp(model.OpSubscribe, h.handleSubscribe)
+	wsServer.HandleOp(model.OpUnsubscribe, h.handleUnsubscribe)
+	wsServer.HandleOp(model.OpMessageCreate, h.handleMessageCreate)
+	wsServer.HandleOp(model.OpMessageEdit, h.handleMessageEdit)
+	wsServer.HandleOp(model.OpMessageDelete, h.handleMessageDelete)
+	wsServer.HandleOp(model.OpTyping, h.handleTyping)
+	wsServer.HandleOp(model.OpReadAck, h.handleReadAck)
+
+	return h
+}
+
+func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tokenString := utils.GetTokenFromCookie(r)
+
+	userID, err := auth.GetUserIDFromToken(tokenString)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+		return
+	}
+
+	codec, err := websocket.RequestedCodec(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	conn, err := ws.Accept(w, r, h.wsServer.AcceptOptions())
+	if err != nil {
+		log.Println("Failed to accept WebSocket connection:", err)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to open WebSocket connection"))
+		return
+	}
+
+	sessionID, lastSeq := resumeParams(r)
+
+	client := h.wsServer.NewClient(utils.RandomID(), userID, conn)
+	if conn.Subprotocol() == "" {
+		client.Encoding = codec.Name()
+	}
+	h.wsServer.Connect(client, sessionID, lastSeq)
+
+	connCtx, stopConn := context.WithCancel(ctx)
+	go h.wsServer.WritePump(connCtx, client)
+	go h.wsServer.Heartbeat(connCtx, client)
+
+	defer func() {
+		stopConn()
+		h.wsServer.Disconnect(client)
+		if err := conn.Close(ws.StatusNormalClosure, "Connection closed"); err != nil {
+			log.Println("Error closing WebSocket connection:", err)
+		}
+	}()
+
+	for {
+		_, frame, err := conn.Read(ctx)
+		if err != nil {
+			log.Println("Error reading message:", err)
+			break
+		}
+
+		h.wsServer.Dispatch(ctx, client, frame)
+	}
+}
+
+// resumeParams reads the session a reconnecting client wants to resume, and
+// the last dispatch seq it received, from the session_id and seq query
+// parameters.
+func resumeParams(r *http.Request) (string, int64) {
+	query := r.URL.Query()
+
+	sessionID := query.Get("session_id")
+	if sessionID == "" {
+		return "", 0
+	}
+
+	lastSeq, err := strconv.ParseInt(query.Get("seq"), 10, 64)
+	if err != nil {
+		lastSeq = -1
+	}
+
+	return sessionID, lastSeq
+}
+
+func (h *WebSocketHandler) handleSubscribe(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.WSSubscribePayload
+	if err := websocket.DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	topic, err := websocket.TopicFor(payload.ChannelID, payload.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverID := ""
+	if payload.ChannelID != "" {
+		channel, err := h.channelService.GetChannel(client.UserID, payload.ChannelID)
+		if err != nil {
+			return nil, err
+		}
+		serverID = channel.ServerID
+	} else if _, err := h.conversationService.GetConversation(client.UserID, payload.ConversationID); err != nil {
+		return nil, err
+	}
+
+	h.wsServer.Subscribe(client, topic, serverID)
+	return nil, nil
+}
+
+func (h *WebSocketHandler) handleUnsubscribe(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.WSSubscribePayload
+	if err := websocket.DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	topic, err := websocket.TopicFor(payload.ChannelID, payload.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	h.wsServer.Unsubscribe(client, topic)
+	return nil, nil
+}
+
+// handleMessageCreate persists a message and acks it with its id. A nonce the
+// user already sent within the nonce window is acked with the id of the
+// message it created instead of creating another one.
+func (h *WebSocketHandler) handleMessageCreate(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.CreateMessagePayload
+	if err := websocket.DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	message, messageID, err := createMessage(h.wsServer, h.messageService, client.UserID, payload)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return &model.WSAckPayload{Nonce: payload.Nonce, MessageID: messageID}, nil
+	}
+
+	return &model.WSAckPayload{Nonce: message.Nonce, MessageID: message.ID}, nil
+}
+
+func (h *WebSocketHandler) handleMessageEdit(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.WSMessageEditPayload
+	if err := websocket.DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	message, err := h.messageService.UpdateMessage(client.UserID, payload.MessageID, model.UpdateMessagePayload{
+		Content: payload.Content,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := publishMessageUpdated(h.wsServer, message); err != nil {
+		return nil, err
+	}
+
+	return &model.WSAckPayload{MessageID: message.ID}, nil
+}
+
+func (h *WebSocketHandler) handleMessageDelete(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.WSMessageDeletePayload
+	if err := websocket.DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	message, err := h.messageService.DeleteMessage(client.UserID, payload.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := publishMessageDeleted(h.wsServer, message); err != nil {
+		return nil, err
+	}
+
+	return &model.WSAckPayload{MessageID: message.ID}, nil
+}
+
+func (h *WebSocketHandler) handleTyping(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.WSTypingPayload
+	if err := websocket.DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	topic, err := websocket.TopicFor(payload.ChannelID, payload.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, h.wsServer.StartTyping(client, topic, payload)
+}
+
+func (h *WebSocketHandler) handleReadAck(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.WSReadAckPayload
+	if err := websocket.DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	state, err := h.messageService.AckMessage(client.UserID, payload.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, publishReadState(h.wsServer, state)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+)
+
+// Category groups channels of a server. Channels are ordered by their
+// position within their category, categories by theirs within the server.
+type Category struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ServerID  string    `json:"server_id"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Channels []Channel `json:"channels,omitempty"`
+}
+
+type CategoryRepository interface {
+	CreateCategory(category Category) (*Category, error)
+	FindCategoryByID(id string) (*Category, error)
+	FindCategoriesByServer(serverID string) ([]Category, error)
+
+	UpdateCategory(category Category) (*Category, error)
+	DeleteCategory(category Category) (*Category, error)
+	ReorderCategories(serverID string, categoryIDs []string) error
+}
+
+type CategoryService interface {
+	CreateCategory(userID, serverID string, createCategoryPayload CreateCategoryPayload) (*Category, error)
+	UpdateCategory(userID, categoryID string, updateCategoryPayload UpdateCategoryPayload) (*Category, error)
+	DeleteCategory(userID, categoryID string) (*Category, error)
+	ReorderCategories(userID, serverID string, reorderCategoriesPayload ReorderCategoriesPayload) ([]Category, error)
+}
+
+type CreateCategoryPayload struct {
+	Name string `json:"name" validate:"required,max=30"`
+}
+
+type UpdateCategoryPayload struct {
+	Name string `json:"name" validate:"required,max=30"`
+}
+
+// ReorderCategoriesPayload lists every category of the server in its new
+// order.
+type ReorderCategoriesPayload struct {
+	CategoryIDs []string `json:"category_ids" validate:"required,min=1,unique,dive,uuid"`
+}
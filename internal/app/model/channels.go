@@ -12,16 +12,74 @@ const (
 	VIDEO ChannelType = "VIDEO"
 )
 
+// Channel belongs to a server and optionally to one of its categories. An
+// empty CategoryID places it among the channels listed before any category.
 type Channel struct {
-	ID        string      `json:"id"`
-	Name      string      `json:"name"`
-	Type      ChannelType `json:"channel_type"`
-	UserID    string      `json:"user_id"`
-	ServerID  string      `json:"server_id"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Type       ChannelType `json:"channel_type"`
+	UserID     string      `json:"user_id"`
+	ServerID   string      `json:"server_id"`
+	CategoryID string      `json:"category_id"`
+	Position   int         `json:"position"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+
+	UnreadCount  int `json:"unread_count"`
+	MentionCount int `json:"mention_count"`
 }
 
 type ChannelRepository interface {
 	CreateChannel(channel Channel) (*Channel, error)
+	FindChannelByID(id string) (*Channel, error)
+	FindChannelsByServer(serverID, userID string) ([]Channel, error)
+
+	UpdateChannel(channel Channel) (*Channel, error)
+	DeleteChannel(channel Channel) (*Channel, error)
+	ReorderChannels(serverID, categoryID string, channelIDs []string) error
+	MoveChannel(channel Channel, categoryID string, position *int) (*Channel, error)
+
+	FindChannelOverwrites(channelID string) ([]ChannelOverwrite, error)
+	FindChannelOverwritesByServer(serverID string) ([]ChannelOverwrite, error)
+	SetChannelOverwrite(overwrite ChannelOverwrite) (*ChannelOverwrite, error)
+	DeleteChannelOverwrite(overwrite ChannelOverwrite) error
+}
+
+type ChannelService interface {
+	GetChannel(userID, channelID string) (*Channel, error)
+	GetServerChannels(userID, serverID string) ([]Channel, error)
+
+	CreateChannel(userID, serverID string, createChannelPayload CreateChannelPayload) (*Channel, error)
+	UpdateChannel(userID, channelID string, updateChannelPayload UpdateChannelPayload) (*Channel, error)
+	DeleteChannel(userID, channelID string) (*Channel, []Member, error)
+	ReorderChannels(userID, serverID string, reorderChannelsPayload ReorderChannelsPayload) ([]Channel, error)
+	MoveChannel(userID, channelID string, moveChannelPayload MoveChannelPayload) ([]Channel, error)
+
+	GetChannelOverwrites(userID, channelID string) ([]ChannelOverwrite, error)
+	SetChannelOverwrite(userID, channelID, roleID string, setChannelOverwritePayload SetChannelOverwritePayload) (*ChannelOverwrite, []Member, error)
+	DeleteChannelOverwrite(userID, channelID, roleID string) ([]Member, error)
+}
+
+type CreateChannelPayload struct {
+	Name       string      `json:"name" validate:"required,max=30"`
+	Type       ChannelType `json:"channel_type" validate:"required,oneof=TEXT AUDIO VIDEO"`
+	CategoryID string      `json:"category_id" validate:"omitempty,uuid"`
+}
+
+type UpdateChannelPayload struct {
+	Name string `json:"name" validate:"required,max=30"`
+}
+
+// ReorderChannelsPayload lists every channel of a category in its new order,
+// or every channel without a category when CategoryID is empty.
+type ReorderChannelsPayload struct {
+	CategoryID string   `json:"category_id" validate:"omitempty,uuid"`
+	ChannelIDs []string `json:"channel_ids" validate:"required,min=1,unique,dive,uuid"`
+}
+
+// MoveChannelPayload moves a channel into a category, or out of any when
+// CategoryID is empty, at Position. A nil Position appends it.
+type MoveChannelPayload struct {
+	CategoryID string `json:"category_id" validate:"omitempty,uuid"`
+	Position   *int   `json:"position,omitempty" validate:"omitempty,min=0"`
 }
@@ -1,7 +1,82 @@
 package model
 
+import (
+	"slices"
+	"time"
+)
+
+type ConversationType string
+
+const (
+	DIRECT ConversationType = "DM"
+	GROUP  ConversationType = "GROUP"
+)
+
+const MaxGroupParticipants = 10
+
+// Conversation is either a direct message thread between two users or a
+// group DM. For direct messages MemberOneID is always the lexically smaller
+// user id so a pair maps to a single row, group DMs list their members in
+// ParticipantIDs instead.
 type Conversation struct {
-	ID          string `json:"id"`
-	MemberOneID string `json:"member_one_id"`
-	MemberTwoID string `json:"member_two_id"`
+	ID             string           `json:"id"`
+	Type           ConversationType `json:"type"`
+	Name           string           `json:"name,omitempty"`
+	OwnerID        string           `json:"owner_id,omitempty"`
+	MemberOneID    string           `json:"member_one_id,omitempty"`
+	MemberTwoID    string           `json:"member_two_id,omitempty"`
+	ParticipantIDs []string         `json:"participant_ids,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+
+	LastMessage  *Message `json:"last_message,omitempty"`
+	UnreadCount  int      `json:"unread_count"`
+	MentionCount int      `json:"mention_count"`
+}
+
+func (c *Conversation) HasParticipant(userID string) bool {
+	if c.Type == GROUP {
+		return slices.Contains(c.ParticipantIDs, userID)
+	}
+	return c.MemberOneID == userID || c.MemberTwoID == userID
+}
+
+type ConversationRepository interface {
+	GetOrCreateConversation(conversation Conversation) (*Conversation, error)
+	CreateGroupConversation(conversation Conversation) (*Conversation, error)
+	FindConversationByID(id string) (*Conversation, error)
+	FindConversationsByUser(userID string) ([]Conversation, error)
+
+	UpdateConversation(conversation Conversation) (*Conversation, error)
+	AddParticipants(conversationID string, userIDs []string) ([]string, error)
+	RemoveParticipant(conversationID, userID string) error
+	DeleteConversation(id string) error
+}
+
+type ConversationService interface {
+	GetOrCreateConversation(userID string, createConversationPayload CreateConversationPayload) (*Conversation, error)
+	GetConversation(userID, conversationID string) (*Conversation, error)
+	GetUserConversations(userID string) ([]Conversation, error)
+
+	CreateGroupConversation(userID string, createGroupPayload CreateGroupConversationPayload) (*Conversation, error)
+	RenameGroupConversation(userID, conversationID string, renamePayload RenameConversationPayload) (*Conversation, *Message, error)
+	AddParticipants(userID, conversationID string, addParticipantsPayload AddParticipantsPayload) (*Conversation, []string, *Message, error)
+	RemoveParticipant(userID, conversationID, targetUserID string) (*Conversation, *Message, error)
+}
+
+type CreateConversationPayload struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+type CreateGroupConversationPayload struct {
+	Name    string   `json:"name" validate:"omitempty,max=100"`
+	UserIDs []string `json:"user_ids" validate:"required,min=1,dive,uuid"`
+}
+
+type RenameConversationPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type AddParticipantsPayload struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,dive,uuid"`
 }
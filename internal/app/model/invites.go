@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+)
+
+// Invite lets users join a server by its code. Every server also has a
+// default invite, its invite_code, which never expires or runs out and is
+// replaced when regenerated. A MaxUses of zero means unlimited.
+type Invite struct {
+	Code      string     `json:"code"`
+	ServerID  string     `json:"server_id"`
+	CreatorID string     `json:"creator_id"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Default   bool       `json:"default"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the invite can still be accepted at now.
+func (i *Invite) Usable(now time.Time) bool {
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+// InvitePreview describes the server an invite leads to, for users who have
+// not joined yet.
+type InvitePreview struct {
+	Code        string     `json:"code"`
+	ServerID    string     `json:"server_id"`
+	ServerName  string     `json:"server_name"`
+	IconURL     string     `json:"icon_url"`
+	MemberCount int        `json:"member_count"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type InviteRepository interface {
+	CreateInvite(invite Invite) (*Invite, error)
+	FindInviteByCode(code string) (*Invite, error)
+	FindInvitesByServer(serverID string) ([]Invite, error)
+
+	AcceptInvite(invite Invite, userID string) (*Member, error)
+	DeleteInvite(invite Invite) (*Invite, error)
+	RegenerateDefaultInvite(serverID string) (*Invite, error)
+}
+
+type InviteService interface {
+	CreateInvite(userID, serverID string, createInvitePayload CreateInvitePayload) (*Invite, error)
+	GetServerInvites(userID, serverID string) ([]Invite, error)
+	PreviewInvite(code string) (*InvitePreview, error)
+	AcceptInvite(userID, code string) (*Member, error)
+
+	RevokeInvite(userID, serverID, code string) (*Invite, error)
+	RegenerateDefaultInvite(userID, serverID string) (*Invite, error)
+}
+
+// CreateInvitePayload limits a new invite. MaxAge is in seconds, and zero for
+// either field means no limit.
+type CreateInvitePayload struct {
+	MaxUses int `json:"max_uses" validate:"omitempty,min=1,max=1000"`
+	MaxAge  int `json:"max_age" validate:"omitempty,min=60,max=2592000"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInviteUsable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		invite Invite
+		want   bool
+	}{
+		{name: "unlimited", invite: Invite{Uses: 1000}, want: true},
+		{name: "default", invite: Invite{Default: true, Uses: 3}, want: true},
+		{name: "uses left", invite: Invite{MaxUses: 2, Uses: 1}, want: true},
+		{name: "ran out", invite: Invite{MaxUses: 2, Uses: 2}, want: false},
+		{name: "not expired", invite: Invite{ExpiresAt: &future}, want: true},
+		{name: "expired", invite: Invite{ExpiresAt: &past}, want: false},
+		{name: "expires now", invite: Invite{ExpiresAt: &now}, want: false},
+		{name: "expired with uses left", invite: Invite{MaxUses: 5, ExpiresAt: &past}, want: false},
+		{name: "ran out before expiring", invite: Invite{MaxUses: 1, Uses: 1, ExpiresAt: &future}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invite.Usable(now); got != tt.want {
+				t.Errorf("Usable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
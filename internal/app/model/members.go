@@ -4,17 +4,11 @@ import (
 	"time"
 )
 
-type Role string
-
-const (
-	ADMIN     Role = "ADMIN"
-	MODERATOR Role = "MODERATOR"
-	GUEST     Role = "GUEST"
-)
-
+// Member is a user in a server. RoleIDs lists the roles assigned to it, the
+// default role of the server is held implicitly.
 type Member struct {
 	ID        string    `json:"id"`
-	Role      Role      `json:"role"`
+	RoleIDs   []string  `json:"role_ids"`
 	UserID    string    `json:"user_id"`
 	ServerID  string    `json:"server_id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -23,4 +17,22 @@ type Member struct {
 
 type MemberRepository interface {
 	CreateMember(member Member) (*Member, error)
+	FindMemberByID(id string) (*Member, error)
+	FindMemberByServer(userID, serverID string) (*Member, error)
+	FindMemberByChannel(userID, channelID string) (*Member, error)
+	FindMembersByServer(serverID string) ([]Member, error)
+	CountMembersByServer(serverID string) (int, error)
+
+	DeleteMember(member Member) (*Member, error)
+	BanMember(member Member, bannedBy string) (*Member, error)
+	IsBanned(userID, serverID string) (bool, error)
+}
+
+type MemberService interface {
+	GetServerMembers(userID, serverID string) ([]Member, error)
+	GetChannelViewers(userID, serverID string, channels []Channel) (map[string][]Member, error)
+	GetHiddenChannels(userID, serverID string) (map[string][]Member, error)
+	LeaveServer(userID, serverID string) (*Member, error)
+	KickMember(userID, serverID, memberID string) (*Member, error)
+	BanMember(userID, serverID, memberID string) (*Member, error)
 }
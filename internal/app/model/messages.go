@@ -1,14 +1,83 @@
 package model
 
-import "time"
+import (
+	"time"
 
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type MessageType string
+
+const (
+	DEFAULT MessageType = "DEFAULT"
+	SYSTEM  MessageType = "SYSTEM"
+)
+
+// Message.Nonce is never stored, it only echoes the client nonce in the
+// message_created event so the sender can match it to its pending message.
+// Message.MemberID is empty for conversation messages and for channel
+// messages whose author has since left the server.
 type Message struct {
-	ID             string    `json:"id"`
-	Content        string    `json:"content"`
-	MemberID       string    `json:"member_id"`
-	ConversationID string    `json:"conversation_id,omitempty"`
-	ChannelID      string    `json:"channel_id,omitempty"`
-	Deleted        bool      `json:"deleted"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             string      `json:"id"`
+	Type           MessageType `json:"type"`
+	Content        string      `json:"content"`
+	UserID         string      `json:"user_id"`
+	MemberID       string      `json:"member_id,omitempty"`
+	ConversationID string      `json:"conversation_id,omitempty"`
+	ChannelID      string      `json:"channel_id,omitempty"`
+	Deleted        bool        `json:"deleted"`
+	EditedAt       *time.Time  `json:"edited_at,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+
+	Nonce string `json:"nonce,omitempty"`
+}
+
+type MessageRepository interface {
+	CreateMessage(message Message) (*Message, error)
+	FindMessageByID(id string) (*Message, error)
+	FindMessagesBefore(field, targetID string, cursor *Message, limit int) ([]Message, error)
+	FindMessagesAfter(field, targetID string, cursor *Message, limit int) ([]Message, error)
+
+	UpdateMessageContent(message Message) (*Message, error)
+	SoftDeleteMessage(message Message) (*Message, error)
+}
+
+type MessageService interface {
+	CreateMessage(userID string, createMessagePayload CreateMessagePayload) (*Message, error)
+	GetMessageByID(id string) (*Message, error)
+	GetChannelMessages(userID, channelID string, pageParams utils.PageParams) (*utils.Page[Message], error)
+	GetConversationMessages(userID, conversationID string, pageParams utils.PageParams) (*utils.Page[Message], error)
+
+	UpdateMessage(userID, messageID string, updateMessagePayload UpdateMessagePayload) (*Message, error)
+	DeleteMessage(userID, messageID string) (*Message, error)
+
+	AckMessage(userID, messageID string) (*ReadState, error)
+}
+
+type SystemMessageKind string
+
+const (
+	SystemParticipantsAdded   SystemMessageKind = "participants_added"
+	SystemParticipantRemoved  SystemMessageKind = "participant_removed"
+	SystemParticipantLeft     SystemMessageKind = "participant_left"
+	SystemConversationRenamed SystemMessageKind = "conversation_renamed"
+)
+
+// SystemMessageContent is stored as JSON in the content of SYSTEM messages.
+type SystemMessageContent struct {
+	Kind    SystemMessageKind `json:"kind"`
+	UserIDs []string          `json:"user_ids,omitempty"`
+	Name    string            `json:"name,omitempty"`
+}
+
+type CreateMessagePayload struct {
+	ChannelID      string `json:"channel_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Content        string `json:"content" validate:"required,max=2000"`
+	Nonce          string `json:"nonce,omitempty" validate:"omitempty,max=64"`
+}
+
+type UpdateMessagePayload struct {
+	Content string `json:"content" validate:"required,max=2000"`
 }
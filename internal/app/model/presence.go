@@ -0,0 +1,11 @@
+package model
+
+// PresenceService combines the status derived from a user's connections,
+// which the websocket hub knows across instances, with the status they
+// picked manually. Every method returning a status also reports whether it
+// changed, in which case it should be broadcast.
+type PresenceService interface {
+	RefreshStatus(userID string, connection ProfileStatus) (ProfileStatus, bool, error)
+	GetPresenceAudience(userID string) ([]string, error)
+	ResetStatuses() error
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ReadState is the last message a user has read in a channel or
+// conversation. Messages after it, sent by someone else, are unread.
+type ReadState struct {
+	UserID         string    `json:"user_id"`
+	ChannelID      string    `json:"channel_id,omitempty"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	LastMessageID  string    `json:"last_message_id"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// MentionToken is how a message mentions a user in its content. Unread
+// messages containing it count towards the user's mention count.
+func MentionToken(userID string) string {
+	return "<@" + userID + ">"
+}
+
+// MentionsEveryone reports whether content mentions every member of the
+// channel with @everyone or @here, which needs PermMentionEveryone.
+func MentionsEveryone(content string) bool {
+	return strings.Contains(content, "@everyone") || strings.Contains(content, "@here")
+}
+
+type ReadStateRepository interface {
+	UpsertReadState(userID string, lastMessage Message) (*ReadState, error)
+	FindReadState(userID, field, targetID string) (*ReadState, error)
+}
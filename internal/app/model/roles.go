@@ -0,0 +1,154 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Permission is a bitset of what the roles of a member allow in a server.
+type Permission int64
+
+const (
+	PermAdministrator Permission = 1 << iota
+	PermManageServer
+	PermManageRoles
+	PermManageChannels
+	PermCreateInvites
+	PermManageInvites
+	PermKickMembers
+	PermBanMembers
+	PermManageMessages
+	PermMentionEveryone
+	PermViewChannels
+
+	PermAll = PermViewChannels<<1 - 1
+
+	// DefaultPermissions are given to the default role of new servers.
+	DefaultPermissions = PermViewChannels | PermCreateInvites
+)
+
+// Has reports whether p grants every permission of perm, which the
+// administrator permission always does.
+func (p Permission) Has(perm Permission) bool {
+	return p&PermAdministrator != 0 || p&perm == perm
+}
+
+// Role is defined by a server and assigned to its members. Roles with a
+// higher Position outrank those below them. The Default role sits at
+// position 0 and is held by every member without being assigned.
+type Role struct {
+	ID          string     `json:"id"`
+	ServerID    string     `json:"server_id"`
+	Name        string     `json:"name"`
+	Color       int        `json:"color"`
+	Position    int        `json:"position"`
+	Permissions Permission `json:"permissions"`
+	Default     bool       `json:"default"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ChannelOverwrite changes the permissions a role grants in one channel:
+// Deny takes permissions away and Allow adds them.
+type ChannelOverwrite struct {
+	ChannelID string     `json:"channel_id"`
+	RoleID    string     `json:"role_id"`
+	Allow     Permission `json:"allow"`
+	Deny      Permission `json:"deny"`
+}
+
+// MemberPermissions is what a member may do in a server, resolved from its
+// roles. The owner of the server may do everything and outranks everyone.
+type MemberPermissions struct {
+	Member        *Member
+	Owner         bool
+	Permissions   Permission
+	TopPosition   int
+	DefaultRoleID string
+}
+
+// ForChannel applies the overwrites of a channel to the permissions: the one
+// of the default role first, then those of the member's roles combined.
+// Owners and administrators are not affected.
+func (p *MemberPermissions) ForChannel(overwrites []ChannelOverwrite) *MemberPermissions {
+	if p.Owner || p.Permissions&PermAdministrator != 0 {
+		return p
+	}
+
+	permissions := p.Permissions
+	var allow, deny Permission
+	for _, overwrite := range overwrites {
+		switch {
+		case overwrite.RoleID == p.DefaultRoleID:
+			permissions = permissions&^overwrite.Deny | overwrite.Allow
+		case slices.Contains(p.Member.RoleIDs, overwrite.RoleID):
+			allow |= overwrite.Allow
+			deny |= overwrite.Deny
+		}
+	}
+
+	resolved := *p
+	resolved.Permissions = permissions&^deny | allow
+	return &resolved
+}
+
+func (p *MemberPermissions) Has(perm Permission) bool {
+	return p.Owner || p.Permissions.Has(perm)
+}
+
+// Outranks reports whether the member sits above position in the role
+// hierarchy.
+func (p *MemberPermissions) Outranks(position int) bool {
+	return p.Owner || p.TopPosition > position
+}
+
+type RoleRepository interface {
+	CreateRole(role Role) (*Role, error)
+	FindRoleByID(id string) (*Role, error)
+	FindRolesByServer(serverID string) ([]Role, error)
+	FindRolesByMember(memberID string) ([]Role, error)
+
+	UpdateRole(id string, updateRolePayload UpdateRolePayload) (*Role, error)
+	DeleteRole(role Role) (*Role, error)
+	ReorderRoles(serverID string, roleIDs []string) error
+
+	AddMemberRole(memberID, roleID string) error
+	RemoveMemberRole(memberID, roleID string) error
+}
+
+// SetChannelOverwritePayload replaces the overwrite of a role in a channel.
+type SetChannelOverwritePayload struct {
+	Allow Permission `json:"allow" validate:"min=0"`
+	Deny  Permission `json:"deny" validate:"min=0"`
+}
+
+type RoleService interface {
+	GetServerRoles(userID, serverID string) ([]Role, error)
+
+	CreateRole(userID, serverID string, createRolePayload CreateRolePayload) (*Role, error)
+	UpdateRole(userID, serverID, roleID string, updateRolePayload UpdateRolePayload) (*Role, error)
+	DeleteRole(userID, serverID, roleID string) (*Role, error)
+	ReorderRoles(userID, serverID string, reorderRolesPayload ReorderRolesPayload) ([]Role, error)
+
+	AddMemberRole(userID, serverID, memberID, roleID string) (*Member, error)
+	RemoveMemberRole(userID, serverID, memberID, roleID string) (*Member, error)
+}
+
+type CreateRolePayload struct {
+	Name        string     `json:"name" validate:"required,max=30"`
+	Color       int        `json:"color" validate:"min=0,max=16777215"`
+	Permissions Permission `json:"permissions" validate:"min=0"`
+}
+
+// UpdateRolePayload changes only the fields that are set.
+type UpdateRolePayload struct {
+	Name        *string     `json:"name,omitempty" validate:"omitempty,min=1,max=30"`
+	Color       *int        `json:"color,omitempty" validate:"omitempty,min=0,max=16777215"`
+	Permissions *Permission `json:"permissions,omitempty" validate:"omitempty,min=0"`
+}
+
+// ReorderRolesPayload lists every role of the server but the default one,
+// from the highest to the lowest.
+type ReorderRolesPayload struct {
+	RoleIDs []string `json:"role_ids" validate:"required,min=1,unique,dive,uuid"`
+}
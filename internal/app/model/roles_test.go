@@ -0,0 +1,153 @@
+package model
+
+import "testing"
+
+func TestPermissionHas(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms Permission
+		perm  Permission
+		want  bool
+	}{
+		{name: "granted", perms: PermKickMembers | PermBanMembers, perm: PermKickMembers, want: true},
+		{name: "not granted", perms: PermKickMembers, perm: PermBanMembers, want: false},
+		{name: "all of several", perms: PermKickMembers | PermBanMembers, perm: PermKickMembers | PermBanMembers, want: true},
+		{name: "only some of several", perms: PermKickMembers, perm: PermKickMembers | PermBanMembers, want: false},
+		{name: "administrator", perms: PermAdministrator, perm: PermManageRoles | PermBanMembers, want: true},
+		{name: "nothing", perms: 0, perm: PermViewChannels, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.perms.Has(tt.perm); got != tt.want {
+				t.Errorf("%b.Has(%b) = %v, want %v", tt.perms, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberPermissionsOutranks(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions MemberPermissions
+		position    int
+		want        bool
+	}{
+		{name: "higher", permissions: MemberPermissions{TopPosition: 3}, position: 2, want: true},
+		{name: "equal", permissions: MemberPermissions{TopPosition: 2}, position: 2, want: false},
+		{name: "lower", permissions: MemberPermissions{TopPosition: 1}, position: 2, want: false},
+		{name: "default role only", permissions: MemberPermissions{}, position: 0, want: false},
+		{name: "administrator", permissions: MemberPermissions{Permissions: PermAdministrator, TopPosition: 1}, position: 2, want: false},
+		{name: "owner", permissions: MemberPermissions{Owner: true}, position: 5, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.permissions.Outranks(tt.position); got != tt.want {
+				t.Errorf("Outranks(%d) = %v, want %v", tt.position, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberPermissionsHas(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions MemberPermissions
+		perm        Permission
+		want        bool
+	}{
+		{name: "granted by a role", permissions: MemberPermissions{Permissions: PermManageChannels}, perm: PermManageChannels, want: true},
+		{name: "not granted", permissions: MemberPermissions{Permissions: PermCreateInvites}, perm: PermManageChannels, want: false},
+		{name: "owner without roles", permissions: MemberPermissions{Owner: true}, perm: PermManageServer, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.permissions.Has(tt.perm); got != tt.want {
+				t.Errorf("Has(%b) = %v, want %v", tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberPermissionsForChannel(t *testing.T) {
+	member := &Member{RoleIDs: []string{"mod", "muted"}}
+	base := MemberPermissions{
+		Member:        member,
+		Permissions:   PermViewChannels | PermCreateInvites | PermManageMessages,
+		DefaultRoleID: "everyone",
+	}
+
+	tests := []struct {
+		name        string
+		permissions MemberPermissions
+		overwrites  []ChannelOverwrite
+		want        Permission
+	}{
+		{
+			name:        "no overwrites",
+			permissions: base,
+			want:        base.Permissions,
+		},
+		{
+			name:        "default role denied",
+			permissions: base,
+			overwrites:  []ChannelOverwrite{{RoleID: "everyone", Deny: PermViewChannels}},
+			want:        PermCreateInvites | PermManageMessages,
+		},
+		{
+			name:        "role allow beats default deny",
+			permissions: base,
+			overwrites: []ChannelOverwrite{
+				{RoleID: "everyone", Deny: PermViewChannels},
+				{RoleID: "mod", Allow: PermViewChannels},
+			},
+			want: base.Permissions,
+		},
+		{
+			name:        "role allow beats role deny",
+			permissions: base,
+			overwrites: []ChannelOverwrite{
+				{RoleID: "muted", Deny: PermViewChannels},
+				{RoleID: "mod", Allow: PermViewChannels},
+			},
+			want: base.Permissions,
+		},
+		{
+			name:        "role deny",
+			permissions: base,
+			overwrites:  []ChannelOverwrite{{RoleID: "muted", Deny: PermManageMessages}},
+			want:        PermViewChannels | PermCreateInvites,
+		},
+		{
+			name:        "roles the member does not hold",
+			permissions: base,
+			overwrites:  []ChannelOverwrite{{RoleID: "admin", Deny: PermViewChannels}},
+			want:        base.Permissions,
+		},
+		{
+			name:        "administrator",
+			permissions: MemberPermissions{Member: member, Permissions: PermAdministrator, DefaultRoleID: "everyone"},
+			overwrites:  []ChannelOverwrite{{RoleID: "everyone", Deny: PermAll}},
+			want:        PermAdministrator,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.permissions.ForChannel(tt.overwrites)
+			if got.Permissions != tt.want {
+				t.Errorf("got %b, want %b", got.Permissions, tt.want)
+			}
+		})
+	}
+
+	t.Run("owner", func(t *testing.T) {
+		owner := MemberPermissions{Member: member, Owner: true, DefaultRoleID: "everyone"}
+		got := owner.ForChannel([]ChannelOverwrite{{RoleID: "everyone", Deny: PermAll}})
+		if !got.Has(PermViewChannels) {
+			t.Errorf("the owner lost access to the channel")
+		}
+	})
+}
@@ -4,26 +4,49 @@ import (
 	"time"
 )
 
+// ServerModel is returned by the server detail with its channel tree. Channel
+// then holds the channels without a category and Categories the others,
+// grouped under their category.
 type ServerModel struct {
 	ID         string    `json:"id"`
 	Name       string    `json:"name"`
+	IconURL    string    `json:"icon_url"`
 	InviteCode string    `json:"invite_code"`
 	UserID     string    `json:"user_id"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 
-	Members []Member  `json:"members,omitempty"`
-	Channel []Channel `json:"channel,omitempty"`
+	Members    []Member   `json:"members,omitempty"`
+	Roles      []Role     `json:"roles,omitempty"`
+	Channel    []Channel  `json:"channel,omitempty"`
+	Categories []Category `json:"categories,omitempty"`
 }
 
 type ServerRepository interface {
 	CreateServerWithDefaults(server ServerModel) (*ServerModel, error)
+	FindServerByID(id string) (*ServerModel, error)
+	FindServersByUser(userID string) ([]ServerModel, error)
+
+	UpdateServer(id string, updateServerPayload UpdateServerPayload) (*ServerModel, error)
+	DeleteServer(server ServerModel) (*ServerModel, error)
 }
 
 type ServerService interface {
 	CreateServerWithMembersAndChannels(createServerPayload CreateServerPayload, userID string) (*ServerModel, error)
+	GetUserServers(userID string) ([]ServerModel, error)
+	GetServer(userID, serverID string) (*ServerModel, error)
+
+	UpdateServer(userID, serverID string, updateServerPayload UpdateServerPayload) (*ServerModel, error)
+	DeleteServer(userID, serverID string) (*ServerModel, error)
 }
 
 type CreateServerPayload struct {
 	Name string `json:"name" validate:"required,min=3,max=30"`
 }
+
+// UpdateServerPayload changes only the fields that are set. An empty IconURL
+// removes the icon.
+type UpdateServerPayload struct {
+	Name    *string `json:"name,omitempty" validate:"omitempty,min=3,max=30"`
+	IconURL *string `json:"icon_url,omitempty" validate:"omitempty,url|eq="`
+}
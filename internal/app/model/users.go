@@ -10,6 +10,9 @@ type UserRepository interface {
 	FindUserByFieldWithProfile(field, value string) (*User, error)
 
 	UpdateUserProfile(profile UserProfile) (*UserProfile, error)
+	UpdateUserStatus(userID string, status ProfileStatus) error
+	ResetUserStatuses() error
+	FindRelatedUserIDs(userID string) ([]string, error)
 	DeleteUser(user User) (*User, error)
 }
 
@@ -61,16 +64,20 @@ const (
 	OFFLINE ProfileStatus = "OFFLINE"
 )
 
+// UserProfile.Status is the presence other users see, derived from the
+// user's connections. ManualStatus is the BUSY, IDLE or OFFLINE (invisible)
+// status the user picked, empty when presence is automatic.
 type UserProfile struct {
-	ID        string        `json:"id"`
-	UserID    string        `json:"user_id"`
-	Name      string        `json:"name"`
-	ImageURL  string        `json:"image_url,omitempty"`
-	BannerURL string        `json:"banner_url,omitempty"`
-	Bio       string        `json:"bio,omitempty"`
-	Status    ProfileStatus `json:"status"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	ID           string        `json:"id"`
+	UserID       string        `json:"user_id"`
+	Name         string        `json:"name"`
+	ImageURL     string        `json:"image_url,omitempty"`
+	BannerURL    string        `json:"banner_url,omitempty"`
+	Bio          string        `json:"bio,omitempty"`
+	Status       ProfileStatus `json:"status"`
+	ManualStatus ProfileStatus `json:"manual_status,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
 }
 
 type UserUpdatePayload struct {
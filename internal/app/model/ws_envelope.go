@@ -0,0 +1,256 @@
+package model
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const WSProtocolVersion = 1
+
+type WSOp string
+
+const (
+	OpHello         WSOp = "hello"
+	OpDispatch      WSOp = "dispatch"
+	OpHeartbeat     WSOp = "heartbeat"
+	OpHeartbeatAck  WSOp = "heartbeat_ack"
+	OpSubscribe     WSOp = "subscribe"
+	OpUnsubscribe   WSOp = "unsubscribe"
+	OpMessageCreate WSOp = "message_create"
+	OpMessageEdit   WSOp = "message_edit"
+	OpMessageDelete WSOp = "message_delete"
+	OpTyping        WSOp = "typing"
+	OpPresence      WSOp = "presence"
+	OpReadAck       WSOp = "read_ack"
+	OpAck           WSOp = "ack"
+	OpError         WSOp = "error"
+)
+
+type WSEventType string
+
+const (
+	EventMessageCreated WSEventType = "message_created"
+	EventMessageUpdated WSEventType = "message_updated"
+	EventMessageDeleted WSEventType = "message_deleted"
+	EventTypingStart    WSEventType = "typing_start"
+	EventTypingStop     WSEventType = "typing_stop"
+	EventPresenceUpdate WSEventType = "presence_update"
+
+	EventConversationCreated WSEventType = "conversation_created"
+	EventConversationUpdated WSEventType = "conversation_updated"
+	EventConversationRemoved WSEventType = "conversation_removed"
+
+	EventMemberUpdated WSEventType = "member_updated"
+	EventMemberRemoved WSEventType = "member_removed"
+
+	EventServerUpdated WSEventType = "server_updated"
+	EventServerDeleted WSEventType = "server_deleted"
+
+	EventChannelCreated WSEventType = "channel_created"
+	EventChannelUpdated WSEventType = "channel_updated"
+	EventChannelDeleted WSEventType = "channel_deleted"
+
+	EventCategoryCreated WSEventType = "category_created"
+	EventCategoryUpdated WSEventType = "category_updated"
+	EventCategoryDeleted WSEventType = "category_deleted"
+
+	EventRoleCreated WSEventType = "role_created"
+	EventRoleUpdated WSEventType = "role_updated"
+	EventRoleDeleted WSEventType = "role_deleted"
+
+	EventReadStateUpdated WSEventType = "read_state_updated"
+)
+
+type WSErrorCode string
+
+const (
+	ErrCodeBadRequest         WSErrorCode = "bad_request"
+	ErrCodeUnsupportedVersion WSErrorCode = "unsupported_version"
+	ErrCodeUnknownOp          WSErrorCode = "unknown_op"
+	ErrCodeForbidden          WSErrorCode = "forbidden"
+	ErrCodeNotFound           WSErrorCode = "not_found"
+	ErrCodeDuplicate          WSErrorCode = "duplicate"
+	ErrCodeRateLimited        WSErrorCode = "rate_limited"
+	ErrCodeInternal           WSErrorCode = "internal"
+)
+
+// WSEnvelope is the frame exchanged over /ws in both directions. Clients set
+// Seq to correlate acks and errors, the server stamps dispatch frames with its
+// own per session counter, which is what clients resume from.
+type WSEnvelope struct {
+	Version int             `json:"v"`
+	Op      WSOp            `json:"op"`
+	Type    WSEventType     `json:"t,omitempty"`
+	Seq     int64           `json:"seq,omitempty"`
+	Data    json.RawMessage `json:"d,omitempty"`
+
+	// encoded is shared with the copies stamped for each recipient, so Data
+	// is converted once per encoding rather than once per connection.
+	encoded *encodedData
+}
+
+type encodedData struct {
+	mu     sync.Mutex
+	byName map[string][]byte
+}
+
+func NewWSEnvelope(op WSOp, eventType WSEventType, data any) (*WSEnvelope, error) {
+	env := &WSEnvelope{
+		Version: WSProtocolVersion,
+		Op:      op,
+		Type:    eventType,
+		encoded: &encodedData{},
+	}
+
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		env.Data = raw
+	}
+
+	return env, nil
+}
+
+// Shared returns env ready to be sent to many connections, with its own cache
+// of encoded data when it was not built by NewWSEnvelope.
+func (e *WSEnvelope) Shared() *WSEnvelope {
+	if e.encoded != nil {
+		return e
+	}
+
+	shared := *e
+	shared.encoded = &encodedData{}
+	return &shared
+}
+
+// EncodedData returns Data converted by encode for the encoding name, reusing
+// the result of an earlier call on the envelope or any copy of it.
+func (e *WSEnvelope) EncodedData(name string, encode func(json.RawMessage) ([]byte, error)) ([]byte, error) {
+	if e.encoded == nil {
+		return encode(e.Data)
+	}
+
+	e.encoded.mu.Lock()
+	defer e.encoded.mu.Unlock()
+
+	if data, ok := e.encoded.byName[name]; ok {
+		return data, nil
+	}
+
+	data, err := encode(e.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.encoded.byName == nil {
+		e.encoded.byName = make(map[string][]byte)
+	}
+	e.encoded.byName[name] = data
+	return data, nil
+}
+
+// WSHelloPayload is the first frame on every connection. Clients must send a
+// heartbeat op at least every HeartbeatInterval milliseconds, and reconnect
+// with SessionID and the last dispatch seq they saw to resume. Resumed is set
+// when the missed events follow, ResyncRequired when the requested session
+// could not be resumed and the client has to refetch its state.
+type WSHelloPayload struct {
+	SessionID         string `json:"session_id"`
+	HeartbeatInterval int64  `json:"heartbeat_interval"`
+	Resumed           bool   `json:"resumed"`
+	ResyncRequired    bool   `json:"resync_required,omitempty"`
+}
+
+type WSSubscribePayload struct {
+	ChannelID      string `json:"channel_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+type WSMessageEditPayload struct {
+	MessageID string `json:"message_id" validate:"required"`
+	Content   string `json:"content" validate:"required,max=2000"`
+}
+
+type WSMessageDeletePayload struct {
+	MessageID string `json:"message_id" validate:"required"`
+}
+
+type WSMessageDeletedPayload struct {
+	MessageID      string `json:"message_id"`
+	ChannelID      string `json:"channel_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// WSTypingPayload is both the typing op and the typing_start and typing_stop
+// events. Timeout tells clients, in milliseconds, how long to show the
+// indicator if typing_stop never arrives.
+type WSTypingPayload struct {
+	UserID         string `json:"user_id,omitempty"`
+	ChannelID      string `json:"channel_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Timeout        int64  `json:"timeout,omitempty"`
+}
+
+type WSPresencePayload struct {
+	UserID string        `json:"user_id,omitempty"`
+	Status ProfileStatus `json:"status"`
+}
+
+type WSRemovalReason string
+
+const (
+	RemovalLeave WSRemovalReason = "leave"
+	RemovalKick  WSRemovalReason = "kick"
+	RemovalBan   WSRemovalReason = "ban"
+)
+
+type WSMemberRemovedPayload struct {
+	ServerID string          `json:"server_id"`
+	MemberID string          `json:"member_id"`
+	UserID   string          `json:"user_id"`
+	Reason   WSRemovalReason `json:"reason"`
+}
+
+type WSServerDeletedPayload struct {
+	ServerID string `json:"server_id"`
+}
+
+type WSChannelDeletedPayload struct {
+	ChannelID string `json:"channel_id"`
+	ServerID  string `json:"server_id"`
+}
+
+type WSCategoryDeletedPayload struct {
+	CategoryID string `json:"category_id"`
+	ServerID   string `json:"server_id"`
+}
+
+type WSRoleDeletedPayload struct {
+	RoleID   string `json:"role_id"`
+	ServerID string `json:"server_id"`
+}
+
+type WSReadAckPayload struct {
+	MessageID string `json:"message_id" validate:"required"`
+}
+
+// WSAckPayload confirms a client op. Nonce echoes the client nonce of a
+// message_create and MessageID is the id of the message the op persisted or
+// changed.
+type WSAckPayload struct {
+	Seq       int64  `json:"seq"`
+	Nonce     string `json:"nonce,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// WSErrorPayload reports a failed op. RetryAfter is set on rate_limited
+// errors to the milliseconds the client should wait before sending the op
+// again.
+type WSErrorPayload struct {
+	Seq        int64       `json:"seq,omitempty"`
+	Code       WSErrorCode `json:"code"`
+	Message    string      `json:"message"`
+	RetryAfter int64       `json:"retry_after,omitempty"`
+}
@@ -1,19 +1,193 @@
-package model
-
-import "github.com/coder/websocket"
-
-type WSType string
-
-const (
-	DM      WSType = "DM"
-	CHANNEL WSType = "CHANNEL"
-)
-
-type WebSocketUser struct {
-	UserID         string          `json:"user_id"`
-	Conn           *websocket.Conn `json:"-"`
-	Type           WSType          `json:"type"`
-	ConversationID string          `json:"conversation_id,omitempty"`
-	ChannelID      string          `json:"channel_id,omitempty"`
-	IsOnline       bool            `json:"is_online"`
-}
+package model
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// WebSocketUser is a single authenticated session. A user may hold several of
+// them at once, one per device or tab, each with its own subscriptions.
+// Subscriptions maps a topic to the id of the server that grants access to
+// it, or to an empty string for conversations.
+//
+// A session outlives its connection for a short resume window: while
+// detached it keeps its subscriptions and records dispatch events in a
+// bounded replay buffer so a reconnecting client can pick up where it left.
+//
+// Encoding names the codec frames are exchanged with. Sessions streamed over
+// Server-Sent Events have no Conn, Stop ends their stream instead.
+type WebSocketUser struct {
+	ID               string             `json:"id"`
+	UserID           string             `json:"user_id"`
+	Conn             *websocket.Conn    `json:"-"`
+	Send             chan *WSEnvelope   `json:"-"`
+	Subscriptions    map[string]string  `json:"-"`
+	IsOnline         bool               `json:"is_online"`
+	ReplayBufferSize int                `json:"-"`
+	Encoding         string             `json:"-"`
+	Stop             context.CancelFunc `json:"-"`
+
+	closing    atomic.Bool
+	lastSeen   atomic.Int64
+	lastActive atomic.Int64
+	idle       atomic.Bool
+
+	mu       sync.Mutex
+	seq      int64
+	replay   []*WSEnvelope
+	detached bool
+}
+
+// Enqueue hands env to the send queue without blocking. Dispatch envelopes
+// are stamped with the next session sequence number and kept for replay
+// first, under the same lock, so the queue order always matches the
+// sequence order. It reports false when the queue is full; a detached
+// session only records the event.
+func (u *WebSocketUser) Enqueue(env *WSEnvelope) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if env.Op == OpDispatch {
+		u.seq++
+		stamped := *env
+		stamped.Seq = u.seq
+		env = &stamped
+
+		if u.ReplayBufferSize > 0 {
+			if len(u.replay) == u.ReplayBufferSize {
+				u.replay = u.replay[1:]
+			}
+			u.replay = append(u.replay, env)
+		}
+	}
+
+	if u.detached {
+		return true
+	}
+
+	return u.push(env)
+}
+
+// Push hands an already stamped envelope to the send queue, used to replay
+// missed events on resume.
+func (u *WebSocketUser) Push(env *WSEnvelope) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.push(env)
+}
+
+func (u *WebSocketUser) push(env *WSEnvelope) bool {
+	select {
+	case u.Send <- env:
+		return true
+	default:
+		return false
+	}
+}
+
+// Detach marks the session as having lost its connection. Further events
+// are only recorded for replay.
+func (u *WebSocketUser) Detach() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.detached = true
+}
+
+// ReplaySince returns the dispatch events stamped after lastSeq. It reports
+// false when some of them already fell out of the replay buffer, or when
+// lastSeq was never sent, in which case the client has to resync.
+func (u *WebSocketUser) ReplaySince(lastSeq int64) ([]*WSEnvelope, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if lastSeq < 0 || lastSeq > u.seq {
+		return nil, false
+	}
+	if lastSeq == u.seq {
+		return nil, true
+	}
+	if len(u.replay) == 0 || u.replay[0].Seq > lastSeq+1 {
+		return nil, false
+	}
+
+	start := lastSeq + 1 - u.replay[0].Seq
+	return append([]*WSEnvelope(nil), u.replay[start:]...), true
+}
+
+// Inherit continues the sequence and replay buffer of a detached session on
+// this connection.
+func (u *WebSocketUser) Inherit(previous *WebSocketUser) {
+	previous.mu.Lock()
+	seq, replay := previous.seq, previous.replay
+	previous.replay = nil
+	previous.mu.Unlock()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.seq = seq
+	u.replay = replay
+}
+
+// Close ends the connection with code and reason, or the event stream of a
+// session without one.
+func (u *WebSocketUser) Close(code websocket.StatusCode, reason string) error {
+	if u.Conn == nil {
+		u.Stop()
+		return nil
+	}
+	return u.Conn.Close(code, reason)
+}
+
+// CloseNow ends the connection without a close handshake.
+func (u *WebSocketUser) CloseNow() error {
+	if u.Conn == nil {
+		u.Stop()
+		return nil
+	}
+	return u.Conn.CloseNow()
+}
+
+// MarkClosing flags the connection for eviction and reports whether this call
+// was the first to do so.
+func (u *WebSocketUser) MarkClosing() bool {
+	return u.closing.CompareAndSwap(false, true)
+}
+
+// Touch records any frame from the client, heartbeats included.
+func (u *WebSocketUser) Touch() {
+	u.lastSeen.Store(time.Now().UnixNano())
+}
+
+func (u *WebSocketUser) LastSeen() time.Time {
+	return time.Unix(0, u.lastSeen.Load())
+}
+
+// MarkActive records user activity, as opposed to heartbeats, and reports
+// whether the connection was idle until now.
+func (u *WebSocketUser) MarkActive() bool {
+	u.lastActive.Store(time.Now().UnixNano())
+	return u.idle.Swap(false)
+}
+
+func (u *WebSocketUser) LastActive() time.Time {
+	return time.Unix(0, u.lastActive.Load())
+}
+
+// SetIdle reports whether the idle state of the connection changed.
+func (u *WebSocketUser) SetIdle(idle bool) bool {
+	if !idle {
+		u.lastActive.Store(time.Now().UnixNano())
+	}
+	return u.idle.Swap(idle) != idle
+}
+
+func (u *WebSocketUser) IsIdle() bool {
+	return u.idle.Load()
+}
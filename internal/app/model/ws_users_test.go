@@ -0,0 +1,92 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+// newTestSession returns a session that has sent dispatch events 1 to sent
+// and keeps the last bufferSize of them.
+func newTestSession(bufferSize, sent int) *WebSocketUser {
+	u := &WebSocketUser{Send: make(chan *WSEnvelope, sent), ReplayBufferSize: bufferSize}
+	for range sent {
+		u.Enqueue(&WSEnvelope{Op: OpDispatch})
+	}
+	return u
+}
+
+func seqs(envs []*WSEnvelope) []int64 {
+	var seqs []int64
+	for _, env := range envs {
+		seqs = append(seqs, env.Seq)
+	}
+	return seqs
+}
+
+func TestWebSocketUserReplaySince(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+		sent       int
+		lastSeq    int64
+		want       []int64
+		ok         bool
+	}{
+		{name: "caught up", bufferSize: 3, sent: 5, lastSeq: 5, ok: true},
+		{name: "nothing sent", bufferSize: 3, sent: 0, lastSeq: 0, ok: true},
+		{name: "missed one", bufferSize: 3, sent: 5, lastSeq: 4, want: []int64{5}, ok: true},
+		{name: "missed the whole buffer", bufferSize: 3, sent: 5, lastSeq: 2, want: []int64{3, 4, 5}, ok: true},
+		{name: "missed more than the buffer", bufferSize: 3, sent: 5, lastSeq: 1, ok: false},
+		{name: "never seen anything", bufferSize: 3, sent: 5, lastSeq: 0, ok: false},
+		{name: "from the start", bufferSize: 10, sent: 3, lastSeq: 0, want: []int64{1, 2, 3}, ok: true},
+		{name: "ahead of the session", bufferSize: 3, sent: 5, lastSeq: 6, ok: false},
+		{name: "negative", bufferSize: 3, sent: 5, lastSeq: -1, ok: false},
+		{name: "without a buffer", bufferSize: 0, sent: 5, lastSeq: 4, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestSession(tt.bufferSize, tt.sent)
+
+			got, ok := u.ReplaySince(tt.lastSeq)
+			if ok != tt.ok || !slices.Equal(seqs(got), tt.want) {
+				t.Errorf("ReplaySince(%d) = %v, %v, want %v, %v", tt.lastSeq, seqs(got), ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestWebSocketUserDetachedRecordsOnly(t *testing.T) {
+	u := newTestSession(3, 2)
+	u.Detach()
+
+	if !u.Enqueue(&WSEnvelope{Op: OpDispatch}) {
+		t.Fatal("Enqueue on a detached session reported a full queue")
+	}
+	if got := len(u.Send); got != 2 {
+		t.Errorf("detached session queued %d envelopes, want 2", got)
+	}
+
+	got, ok := u.ReplaySince(2)
+	if want := []int64{3}; !ok || !slices.Equal(seqs(got), want) {
+		t.Errorf("ReplaySince(2) = %v, %v, want %v, true", seqs(got), ok, want)
+	}
+}
+
+func TestWebSocketUserInherit(t *testing.T) {
+	previous := newTestSession(3, 4)
+	previous.Detach()
+
+	u := &WebSocketUser{Send: make(chan *WSEnvelope, 1), ReplayBufferSize: 3}
+	u.Inherit(previous)
+	u.Enqueue(&WSEnvelope{Op: OpDispatch})
+
+	if env := <-u.Send; env.Seq != 5 {
+		t.Errorf("first event on the resumed session has seq %d, want 5", env.Seq)
+	}
+
+	got, ok := u.ReplaySince(2)
+	if want := []int64{3, 4, 5}; !ok || !slices.Equal(seqs(got), want) {
+		t.Errorf("ReplaySince(2) = %v, %v, want %v, true", seqs(got), ok, want)
+	}
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/app/repository/helper"
+)
+
+const categoryColumns = "id, name, server_id, position, created_at, updated_at"
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// CreateCategory adds a category after the last one of its server.
+func (r *CategoryRepository) CreateCategory(category model.Category) (*model.Category, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO categories (name, server_id, position)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(position) + 1, 0) FROM categories WHERE server_id = $2))
+		RETURNING %s
+	`, categoryColumns)
+
+	created := &model.Category{}
+	err := scanCategory(r.db.QueryRow(query, category.Name, category.ServerID), created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create category: %v", err)
+	}
+
+	return created, nil
+}
+
+func (r *CategoryRepository) FindCategoryByID(id string) (*model.Category, error) {
+	query := fmt.Sprintf("SELECT %s FROM categories WHERE id = $1", categoryColumns)
+
+	category := &model.Category{}
+	err := scanCategory(r.db.QueryRow(query, id), category)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch category: %v", err)
+	}
+
+	return category, nil
+}
+
+// FindCategoriesByServer lists the categories of a server in order.
+func (r *CategoryRepository) FindCategoriesByServer(serverID string) ([]model.Category, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM categories
+		WHERE server_id = $1
+		ORDER BY position ASC, created_at ASC, id ASC
+	`, categoryColumns)
+
+	rows, err := r.db.Query(query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %v", err)
+	}
+	defer rows.Close()
+
+	categories := []model.Category{}
+	for rows.Next() {
+		var category model.Category
+		err := scanCategory(rows, &category)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %v", err)
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %v", err)
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepository) UpdateCategory(category model.Category) (*model.Category, error) {
+	query := fmt.Sprintf(`
+		UPDATE categories
+		SET name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING %s
+	`, categoryColumns)
+
+	updated := &model.Category{}
+	err := scanCategory(r.db.QueryRow(query, category.Name, category.ID), updated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update category: %v", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteCategory removes a category and appends its channels, in their order,
+// to the channels without a category. The category is returned with the moved
+// channels.
+func (r *CategoryRepository) DeleteCategory(category model.Category) (*model.Category, error) {
+	return helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Category, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", category.ServerID); err != nil {
+			return nil, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		moveQuery := fmt.Sprintf(`
+			WITH moved AS (
+				UPDATE channels c
+				SET category_id = NULL, position = base.next_position + c.position, updated_at = CURRENT_TIMESTAMP
+				FROM (
+					SELECT COALESCE(MAX(position) + 1, 0) AS next_position
+					FROM channels
+					WHERE server_id = $1 AND category_id IS NULL
+				) base
+				WHERE c.category_id = $2
+				RETURNING %s
+			)
+			SELECT * FROM moved ORDER BY position ASC
+		`, channelColumns)
+
+		rows, err := tx.Query(moveQuery, category.ServerID, category.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move channels: %v", err)
+		}
+		defer rows.Close()
+
+		channels := []model.Channel{}
+		for rows.Next() {
+			var channel model.Channel
+			err := scanChannel(rows, &channel)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan channel: %v", err)
+			}
+			channels = append(channels, channel)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to move channels: %v", err)
+		}
+
+		query := fmt.Sprintf("DELETE FROM categories WHERE id = $1 RETURNING %s", categoryColumns)
+
+		deleted := &model.Category{}
+		err = scanCategory(tx.QueryRow(query, category.ID), deleted)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("category %w", model.ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to delete category: %v", err)
+		}
+
+		deleted.Channels = channels
+		return deleted, nil
+	})
+}
+
+// ReorderCategories sets the position of every category of a server to its
+// index in categoryIDs, which has to list each of them exactly once.
+func (r *CategoryRepository) ReorderCategories(serverID string, categoryIDs []string) error {
+	_, err := helper.ExecWithTx(r.db, func(tx *sql.Tx) (struct{}, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", serverID); err != nil {
+			return struct{}{}, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		var count int
+		countQuery := "SELECT COUNT(*) FROM categories WHERE server_id = $1"
+		if err := tx.QueryRow(countQuery, serverID).Scan(&count); err != nil {
+			return struct{}{}, fmt.Errorf("failed to count categories: %v", err)
+		}
+
+		query := `
+			UPDATE categories c
+			SET position = ordered.position - 1, updated_at = CURRENT_TIMESTAMP
+			FROM unnest($2::text[]) WITH ORDINALITY AS ordered(id, position)
+			WHERE c.id = ordered.id::uuid AND c.server_id = $1
+		`
+
+		result, err := tx.Exec(query, serverID, categoryIDs)
+		if err != nil {
+			return struct{}{}, fmt.Errorf("failed to reorder categories: %v", err)
+		}
+
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return struct{}{}, fmt.Errorf("failed to reorder categories: %v", err)
+		}
+
+		if int(updated) != len(categoryIDs) || len(categoryIDs) != count {
+			return struct{}{}, fmt.Errorf("%w: category_ids must list every category of the server once", model.ErrInvalid)
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func scanCategory(row interface{ Scan(dest ...any) error }, category *model.Category) error {
+	return row.Scan(
+		&category.ID,
+		&category.Name,
+		&category.ServerID,
+		&category.Position,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+}
@@ -5,8 +5,17 @@ import (
 	"fmt"
 
 	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/app/repository/helper"
 )
 
+const channelColumns = `
+	id, name, type, user_id, server_id, COALESCE(category_id::text, ''), position, created_at, updated_at
+`
+
+// sameCategory matches the channels of a category, or those without one when
+// the category parameter is empty.
+const sameCategory = "category_id IS NOT DISTINCT FROM NULLIF(%s, '')::uuid"
+
 type ChannelRepository struct {
 	db *sql.DB
 }
@@ -15,8 +24,15 @@ func NewChannelRepository(db *sql.DB) *ChannelRepository {
 	return &ChannelRepository{db: db}
 }
 
+// CreateChannel adds a channel after the last one of its category.
 func (r *ChannelRepository) CreateChannel(channel model.Channel) (*model.Channel, error) {
-	query := "INSERT INTO channels (name, type, user_id, server_id) VALUES ($1,$2,$3,$4) RETURNING id, created_at, updated_at"
+	query := fmt.Sprintf(`
+		INSERT INTO channels (name, type, user_id, server_id, category_id, position)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, (
+			SELECT COALESCE(MAX(position) + 1, 0) FROM channels WHERE server_id = $4 AND %s
+		))
+		RETURNING id, position, created_at, updated_at
+	`, fmt.Sprintf(sameCategory, "$5"))
 
 	stmt, err := r.db.Prepare(query)
 	if err != nil {
@@ -29,8 +45,10 @@ func (r *ChannelRepository) CreateChannel(channel model.Channel) (*model.Channel
 		channel.Type,
 		channel.UserID,
 		channel.ServerID,
+		channel.CategoryID,
 	).Scan(
 		&channel.ID,
+		&channel.Position,
 		&channel.CreatedAt,
 		&channel.UpdatedAt,
 	)
@@ -40,3 +58,320 @@ func (r *ChannelRepository) CreateChannel(channel model.Channel) (*model.Channel
 
 	return &channel, nil
 }
+
+func (r *ChannelRepository) FindChannelByID(id string) (*model.Channel, error) {
+	query := fmt.Sprintf("SELECT %s FROM channels WHERE id = $1", channelColumns)
+
+	channel := &model.Channel{}
+	err := scanChannel(r.db.QueryRow(query, id), channel)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("channel %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch channel: %v", err)
+	}
+
+	return channel, nil
+}
+
+// FindChannelsByServer lists the channels of a server in order, with their
+// unread and mention counts for userID.
+func (r *ChannelRepository) FindChannelsByServer(serverID, userID string) ([]model.Channel, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			c.id, c.name, c.type, c.user_id, c.server_id, COALESCE(c.category_id::text, ''),
+			c.position, c.created_at, c.updated_at,
+			rc.unread_count, rc.mention_count
+		FROM channels c
+		%s
+		WHERE c.server_id = $2
+		ORDER BY c.position ASC, c.created_at ASC, c.id ASC
+	`, unreadCountsJoin("channel_id", "c.id", "$1"))
+
+	rows, err := r.db.Query(query, userID, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channels: %v", err)
+	}
+	defer rows.Close()
+
+	channels := []model.Channel{}
+	for rows.Next() {
+		var channel model.Channel
+		err := rows.Scan(
+			&channel.ID,
+			&channel.Name,
+			&channel.Type,
+			&channel.UserID,
+			&channel.ServerID,
+			&channel.CategoryID,
+			&channel.Position,
+			&channel.CreatedAt,
+			&channel.UpdatedAt,
+			&channel.UnreadCount,
+			&channel.MentionCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel: %v", err)
+		}
+		channels = append(channels, channel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch channels: %v", err)
+	}
+
+	return channels, nil
+}
+
+func (r *ChannelRepository) UpdateChannel(channel model.Channel) (*model.Channel, error) {
+	query := fmt.Sprintf(`
+		UPDATE channels
+		SET name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING %s
+	`, channelColumns)
+
+	updated := &model.Channel{}
+	err := scanChannel(r.db.QueryRow(query, channel.Name, channel.ID), updated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("channel %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update channel: %v", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteChannel removes a channel and its messages, refusing to remove the
+// last text channel of its server. The server row is locked so that two
+// deletes cannot each leave the other as the last one.
+func (r *ChannelRepository) DeleteChannel(channel model.Channel) (*model.Channel, error) {
+	return helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Channel, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", channel.ServerID); err != nil {
+			return nil, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		query := fmt.Sprintf("DELETE FROM channels WHERE id = $1 RETURNING %s", channelColumns)
+
+		deleted := &model.Channel{}
+		err := scanChannel(tx.QueryRow(query, channel.ID), deleted)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("channel %w", model.ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to delete channel: %v", err)
+		}
+
+		if deleted.Type == model.TEXT {
+			var remaining int
+			countQuery := "SELECT COUNT(*) FROM channels WHERE server_id = $1 AND type = $2"
+			if err := tx.QueryRow(countQuery, deleted.ServerID, model.TEXT).Scan(&remaining); err != nil {
+				return nil, fmt.Errorf("failed to count channels: %v", err)
+			}
+			if remaining == 0 {
+				return nil, fmt.Errorf("%w: a server needs at least one text channel", model.ErrInvalid)
+			}
+		}
+
+		return deleted, nil
+	})
+}
+
+// ReorderChannels sets the position of every channel of a category, or of
+// those without one when categoryID is empty, to its index in channelIDs,
+// which has to list each of them exactly once.
+func (r *ChannelRepository) ReorderChannels(serverID, categoryID string, channelIDs []string) error {
+	_, err := helper.ExecWithTx(r.db, func(tx *sql.Tx) (struct{}, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", serverID); err != nil {
+			return struct{}{}, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		var count int
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM channels WHERE server_id = $1 AND %s", fmt.Sprintf(sameCategory, "$2"))
+		if err := tx.QueryRow(countQuery, serverID, categoryID).Scan(&count); err != nil {
+			return struct{}{}, fmt.Errorf("failed to count channels: %v", err)
+		}
+
+		query := fmt.Sprintf(`
+			UPDATE channels c
+			SET position = ordered.position - 1, updated_at = CURRENT_TIMESTAMP
+			FROM unnest($3::text[]) WITH ORDINALITY AS ordered(id, position)
+			WHERE c.id = ordered.id::uuid AND c.server_id = $1 AND c.%s
+		`, fmt.Sprintf(sameCategory, "$2"))
+
+		result, err := tx.Exec(query, serverID, categoryID, channelIDs)
+		if err != nil {
+			return struct{}{}, fmt.Errorf("failed to reorder channels: %v", err)
+		}
+
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return struct{}{}, fmt.Errorf("failed to reorder channels: %v", err)
+		}
+
+		if int(updated) != len(channelIDs) || len(channelIDs) != count {
+			return struct{}{}, fmt.Errorf("%w: channel_ids must list every channel of the category once", model.ErrInvalid)
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+// MoveChannel moves a channel into a category, or out of any when categoryID
+// is empty, at position, or after its last channel when position is nil. The
+// channels after it in both categories are shifted to keep their order.
+func (r *ChannelRepository) MoveChannel(channel model.Channel, categoryID string, position *int) (*model.Channel, error) {
+	return helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Channel, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", channel.ServerID); err != nil {
+			return nil, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		current := &model.Channel{}
+		query := fmt.Sprintf("SELECT %s FROM channels WHERE id = $1", channelColumns)
+		if err := scanChannel(tx.QueryRow(query, channel.ID), current); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("channel %w", model.ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to fetch channel: %v", err)
+		}
+
+		closeQuery := fmt.Sprintf(`
+			UPDATE channels SET position = position - 1, updated_at = CURRENT_TIMESTAMP
+			WHERE server_id = $1 AND %s AND position > $3 AND id <> $4
+		`, fmt.Sprintf(sameCategory, "$2"))
+		if _, err := tx.Exec(closeQuery, current.ServerID, current.CategoryID, current.Position, current.ID); err != nil {
+			return nil, fmt.Errorf("failed to move channel: %v", err)
+		}
+
+		var count int
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM channels WHERE server_id = $1 AND %s AND id <> $3", fmt.Sprintf(sameCategory, "$2"))
+		if err := tx.QueryRow(countQuery, current.ServerID, categoryID, current.ID).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count channels: %v", err)
+		}
+
+		target := count
+		if position != nil && *position < count {
+			target = *position
+		}
+
+		openQuery := fmt.Sprintf(`
+			UPDATE channels SET position = position + 1, updated_at = CURRENT_TIMESTAMP
+			WHERE server_id = $1 AND %s AND position >= $3 AND id <> $4
+		`, fmt.Sprintf(sameCategory, "$2"))
+		if _, err := tx.Exec(openQuery, current.ServerID, categoryID, target, current.ID); err != nil {
+			return nil, fmt.Errorf("failed to move channel: %v", err)
+		}
+
+		moveQuery := fmt.Sprintf(`
+			UPDATE channels
+			SET category_id = NULLIF($1, '')::uuid, position = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+			RETURNING %s
+		`, channelColumns)
+
+		moved := &model.Channel{}
+		if err := scanChannel(tx.QueryRow(moveQuery, categoryID, target, current.ID), moved); err != nil {
+			return nil, fmt.Errorf("failed to move channel: %v", err)
+		}
+
+		return moved, nil
+	})
+}
+
+func (r *ChannelRepository) FindChannelOverwrites(channelID string) ([]model.ChannelOverwrite, error) {
+	query := "SELECT channel_id, role_id, allow, deny FROM channel_overwrites WHERE channel_id = $1"
+
+	return r.queryOverwrites(query, channelID)
+}
+
+// FindChannelOverwritesByServer lists the overwrites of every channel of a
+// server.
+func (r *ChannelRepository) FindChannelOverwritesByServer(serverID string) ([]model.ChannelOverwrite, error) {
+	query := `
+		SELECT o.channel_id, o.role_id, o.allow, o.deny
+		FROM channel_overwrites o
+		JOIN channels c ON c.id = o.channel_id
+		WHERE c.server_id = $1
+	`
+
+	return r.queryOverwrites(query, serverID)
+}
+
+func (r *ChannelRepository) queryOverwrites(query string, args ...any) ([]model.ChannelOverwrite, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channel overwrites: %v", err)
+	}
+	defer rows.Close()
+
+	overwrites := []model.ChannelOverwrite{}
+	for rows.Next() {
+		var overwrite model.ChannelOverwrite
+		err := rows.Scan(
+			&overwrite.ChannelID,
+			&overwrite.RoleID,
+			&overwrite.Allow,
+			&overwrite.Deny,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel overwrite: %v", err)
+		}
+		overwrites = append(overwrites, overwrite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch channel overwrites: %v", err)
+	}
+
+	return overwrites, nil
+}
+
+func (r *ChannelRepository) SetChannelOverwrite(overwrite model.ChannelOverwrite) (*model.ChannelOverwrite, error) {
+	query := `
+		INSERT INTO channel_overwrites (channel_id, role_id, allow, deny)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (channel_id, role_id) DO UPDATE SET allow = EXCLUDED.allow, deny = EXCLUDED.deny
+	`
+
+	_, err := r.db.Exec(query, overwrite.ChannelID, overwrite.RoleID, overwrite.Allow, overwrite.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set channel overwrite: %v", err)
+	}
+
+	return &overwrite, nil
+}
+
+func (r *ChannelRepository) DeleteChannelOverwrite(overwrite model.ChannelOverwrite) error {
+	query := "DELETE FROM channel_overwrites WHERE channel_id = $1 AND role_id = $2"
+
+	result, err := r.db.Exec(query, overwrite.ChannelID, overwrite.RoleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete channel overwrite: %v", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete channel overwrite: %v", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("channel overwrite %w", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanChannel(row interface{ Scan(dest ...any) error }, channel *model.Channel) error {
+	return row.Scan(
+		&channel.ID,
+		&channel.Name,
+		&channel.Type,
+		&channel.UserID,
+		&channel.ServerID,
+		&channel.CategoryID,
+		&channel.Position,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+}
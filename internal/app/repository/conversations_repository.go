@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/app/repository/helper"
+)
+
+const conversationColumns = `
+	c.id, c.type, COALESCE(c.name, ''), COALESCE(c.owner_id::text, ''),
+	COALESCE(c.member_one_id::text, ''), COALESCE(c.member_two_id::text, ''),
+	c.created_at, c.updated_at
+`
+
+type ConversationRepository struct {
+	db *sql.DB
+}
+
+func NewConversationRepository(db *sql.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+func (r *ConversationRepository) GetOrCreateConversation(conversation model.Conversation) (*model.Conversation, error) {
+	query := `
+		INSERT INTO conversations (type, member_one_id, member_two_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (member_one_id, member_two_id)
+		DO UPDATE SET member_one_id = EXCLUDED.member_one_id
+		RETURNING id, created_at, updated_at
+	`
+
+	conversation.Type = model.DIRECT
+	err := r.db.QueryRow(
+		query,
+		conversation.Type,
+		conversation.MemberOneID,
+		conversation.MemberTwoID,
+	).Scan(
+		&conversation.ID,
+		&conversation.CreatedAt,
+		&conversation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create conversation: %v", err)
+	}
+
+	return &conversation, nil
+}
+
+func (r *ConversationRepository) CreateGroupConversation(conversation model.Conversation) (*model.Conversation, error) {
+	result, err := helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Conversation, error) {
+		conversationQuery := `
+			INSERT INTO conversations (type, name, owner_id)
+			VALUES ($1, NULLIF($2, ''), $3)
+			RETURNING id, created_at, updated_at
+		`
+		conversation.Type = model.GROUP
+		err := tx.QueryRow(
+			conversationQuery,
+			conversation.Type,
+			conversation.Name,
+			conversation.OwnerID,
+		).Scan(
+			&conversation.ID,
+			&conversation.CreatedAt,
+			&conversation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create conversation: %v", err)
+		}
+
+		if _, err := insertParticipants(tx, conversation.ID, conversation.ParticipantIDs); err != nil {
+			return nil, err
+		}
+
+		return &conversation, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group conversation: %v", err)
+	}
+
+	return result, nil
+}
+
+func (r *ConversationRepository) FindConversationByID(id string) (*model.Conversation, error) {
+	query := fmt.Sprintf("SELECT %s FROM conversations c WHERE c.id = $1", conversationColumns)
+
+	conversation := &model.Conversation{}
+	err := scanConversation(r.db.QueryRow(query, id), conversation)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("conversation %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch conversation: %v", err)
+	}
+
+	if conversation.Type == model.GROUP {
+		participantIDs, err := r.findParticipantIDs(conversation.ID)
+		if err != nil {
+			return nil, err
+		}
+		conversation.ParticipantIDs = participantIDs
+	}
+
+	return conversation, nil
+}
+
+// FindConversationsByUser lists the user's conversations, most recently
+// active first, with their unread and mention counts.
+func (r *ConversationRepository) FindConversationsByUser(userID string) ([]model.Conversation, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			%s,
+			lm.id, lm.type, lm.content, lm.user_id, lm.edited_at, lm.created_at, lm.updated_at,
+			rc.unread_count, rc.mention_count
+		FROM conversations c
+		%s
+		LEFT JOIN LATERAL (
+			SELECT id, type, content, user_id, edited_at, created_at, updated_at
+			FROM messages
+			WHERE conversation_id = c.id AND deleted = FALSE
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		) lm ON TRUE
+		WHERE c.member_one_id = $1 OR c.member_two_id = $1
+		OR EXISTS (
+			SELECT 1 FROM conversation_participants p
+			WHERE p.conversation_id = c.id AND p.user_id = $1
+		)
+		ORDER BY COALESCE(lm.created_at, c.created_at) DESC
+	`, conversationColumns, unreadCountsJoin("conversation_id", "c.id", "$1"))
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch conversations: %v", err)
+	}
+	defer rows.Close()
+
+	conversations := []model.Conversation{}
+	for rows.Next() {
+		var (
+			conversation  model.Conversation
+			lastID        *string
+			lastType      *model.MessageType
+			lastContent   *string
+			lastUserID    *string
+			lastEditedAt  *time.Time
+			lastCreatedAt *time.Time
+			lastUpdatedAt *time.Time
+		)
+		err := rows.Scan(
+			&conversation.ID,
+			&conversation.Type,
+			&conversation.Name,
+			&conversation.OwnerID,
+			&conversation.MemberOneID,
+			&conversation.MemberTwoID,
+			&conversation.CreatedAt,
+			&conversation.UpdatedAt,
+			&lastID,
+			&lastType,
+			&lastContent,
+			&lastUserID,
+			&lastEditedAt,
+			&lastCreatedAt,
+			&lastUpdatedAt,
+			&conversation.UnreadCount,
+			&conversation.MentionCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %v", err)
+		}
+
+		if lastID != nil {
+			conversation.LastMessage = &model.Message{
+				ID:             *lastID,
+				Type:           *lastType,
+				Content:        *lastContent,
+				UserID:         *lastUserID,
+				ConversationID: conversation.ID,
+				EditedAt:       lastEditedAt,
+				CreatedAt:      *lastCreatedAt,
+				UpdatedAt:      *lastUpdatedAt,
+			}
+		}
+
+		conversations = append(conversations, conversation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch conversations: %v", err)
+	}
+
+	for i := range conversations {
+		if conversations[i].Type != model.GROUP {
+			continue
+		}
+		participantIDs, err := r.findParticipantIDs(conversations[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		conversations[i].ParticipantIDs = participantIDs
+	}
+
+	return conversations, nil
+}
+
+func (r *ConversationRepository) UpdateConversation(conversation model.Conversation) (*model.Conversation, error) {
+	query := `
+		UPDATE conversations
+		SET name = NULLIF($1, ''), owner_id = NULLIF($2, '')::uuid, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		conversation.Name,
+		conversation.OwnerID,
+		conversation.ID,
+	).Scan(&conversation.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("conversation %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update conversation: %v", err)
+	}
+
+	return &conversation, nil
+}
+
+// AddParticipants returns the users who were added, leaving out those who
+// already took part in the conversation.
+func (r *ConversationRepository) AddParticipants(conversationID string, userIDs []string) ([]string, error) {
+	added, err := helper.ExecWithTx(r.db, func(tx *sql.Tx) ([]string, error) {
+		return insertParticipants(tx, conversationID, userIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add participants: %v", err)
+	}
+
+	return added, nil
+}
+
+func (r *ConversationRepository) RemoveParticipant(conversationID, userID string) error {
+	query := "DELETE FROM conversation_participants WHERE conversation_id = $1 AND user_id = $2"
+
+	result, err := r.db.Exec(query, conversationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove participant: %v", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("participant %w", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) DeleteConversation(id string) error {
+	query := "DELETE FROM conversations WHERE id = $1"
+
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to delete conversation: %v", err)
+	}
+
+	return nil
+}
+
+// findParticipantIDs returns the participants of a group DM, oldest first.
+func (r *ConversationRepository) findParticipantIDs(conversationID string) ([]string, error) {
+	query := "SELECT user_id FROM conversation_participants WHERE conversation_id = $1 ORDER BY created_at, user_id"
+
+	rows, err := r.db.Query(query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch participants: %v", err)
+	}
+	defer rows.Close()
+
+	participantIDs := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan participant: %v", err)
+		}
+		participantIDs = append(participantIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch participants: %v", err)
+	}
+
+	return participantIDs, nil
+}
+
+func insertParticipants(tx *sql.Tx, conversationID string, userIDs []string) ([]string, error) {
+	query := `
+		INSERT INTO conversation_participants (conversation_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	inserted := []string{}
+	for _, userID := range userIDs {
+		result, err := tx.Exec(query, conversationID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add participant: %v", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			inserted = append(inserted, userID)
+		}
+	}
+
+	return inserted, nil
+}
+
+func scanConversation(row interface{ Scan(dest ...any) error }, conversation *model.Conversation) error {
+	return row.Scan(
+		&conversation.ID,
+		&conversation.Type,
+		&conversation.Name,
+		&conversation.OwnerID,
+		&conversation.MemberOneID,
+		&conversation.MemberTwoID,
+		&conversation.CreatedAt,
+		&conversation.UpdatedAt,
+	)
+}
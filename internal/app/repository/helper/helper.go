@@ -2,15 +2,17 @@ package helper
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-)
 
-func ExecWithTx[T any](db *sql.DB, fn func(tx *sql.Tx) (T, error)) (T, error) {
-	var zero T
+	"github.com/jackc/pgx/v5/pgconn"
+)
 
+func ExecWithTx[T any](db *sql.DB, fn func(tx *sql.Tx) (T, error)) (result T, err error) {
 	tx, err := db.Begin()
 	if err != nil {
-		return zero, fmt.Errorf("failed to begin transaction: %w", err)
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	defer func() {
@@ -19,15 +21,38 @@ func ExecWithTx[T any](db *sql.DB, fn func(tx *sql.Tx) (T, error)) (T, error) {
 			panic(p)
 		} else if err != nil {
 			tx.Rollback()
-		} else {
-			err = tx.Commit()
+		} else if err = tx.Commit(); err != nil {
+			var zero T
+			result, err = zero, fmt.Errorf("failed to commit transaction: %w", err)
 		}
 	}()
 
-	result, err := fn(tx)
-	if err != nil {
-		return result, err
-	}
+	return fn(tx)
+}
+
+// IsUniqueViolation reports whether err comes from inserting a row that
+// breaks the unique index or constraint named constraint.
+func IsUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// JSON scans a json column, such as the result of json_agg, into dest.
+func JSON(dest any) sql.Scanner {
+	return jsonScanner{dest: dest}
+}
 
-	return result, nil
+type jsonScanner struct {
+	dest any
+}
+
+func (s jsonScanner) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s.dest)
+	case string:
+		return json.Unmarshal([]byte(v), s.dest)
+	default:
+		return fmt.Errorf("cannot scan %T as json", src)
+	}
 }
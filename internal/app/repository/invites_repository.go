@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/app/repository/helper"
+)
+
+// invitesQuery selects the invites of the server_invites table together with
+// the default invite of every server, as code, server_id, creator_id,
+// max_uses, uses, expires_at, is_default and created_at.
+const invitesQuery = `
+	SELECT * FROM (
+		SELECT
+			code, server_id, COALESCE(creator_id::text, '') AS creator_id,
+			COALESCE(max_uses, 0) AS max_uses, uses, expires_at,
+			FALSE AS is_default, created_at
+		FROM server_invites
+		UNION ALL
+		SELECT
+			invite_code::text, id, user_id::text,
+			0, 0, NULL,
+			TRUE, created_at
+		FROM servers
+	) invites
+`
+
+type InviteRepository struct {
+	db *sql.DB
+}
+
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{db: db}
+}
+
+func (r *InviteRepository) CreateInvite(invite model.Invite) (*model.Invite, error) {
+	query := `
+		INSERT INTO server_invites (server_id, creator_id, max_uses, expires_at)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, 0), $4)
+		RETURNING code, created_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		invite.ServerID,
+		invite.CreatorID,
+		invite.MaxUses,
+		invite.ExpiresAt,
+	).Scan(
+		&invite.Code,
+		&invite.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invite: %v", err)
+	}
+
+	return &invite, nil
+}
+
+func (r *InviteRepository) FindInviteByCode(code string) (*model.Invite, error) {
+	query := invitesQuery + "WHERE code = $1"
+
+	invite := &model.Invite{}
+	err := scanInvite(r.db.QueryRow(query, code), invite)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invite %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch invite: %v", err)
+	}
+
+	return invite, nil
+}
+
+// FindInvitesByServer lists the invites of a server, the default one first
+// and the others newest first.
+func (r *InviteRepository) FindInvitesByServer(serverID string) ([]model.Invite, error) {
+	query := invitesQuery + `
+		WHERE server_id = $1
+		ORDER BY is_default DESC, created_at DESC, code ASC
+	`
+
+	rows, err := r.db.Query(query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invites: %v", err)
+	}
+	defer rows.Close()
+
+	invites := []model.Invite{}
+	for rows.Next() {
+		var invite model.Invite
+		if err := scanInvite(rows, &invite); err != nil {
+			return nil, fmt.Errorf("failed to scan invite: %v", err)
+		}
+		invites = append(invites, invite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch invites: %v", err)
+	}
+
+	return invites, nil
+}
+
+// AcceptInvite counts a use of the invite and makes userID a member of its
+// server in one transaction. It fails with ErrInvalid when the invite
+// expired or ran out in the meantime and with ErrConflict when the user
+// already joined.
+func (r *InviteRepository) AcceptInvite(invite model.Invite, userID string) (*model.Member, error) {
+	return helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Member, error) {
+		if !invite.Default {
+			useQuery := `
+				UPDATE server_invites
+				SET uses = uses + 1
+				WHERE code = $1
+				AND (max_uses IS NULL OR uses < max_uses)
+				AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+			`
+
+			result, err := tx.Exec(useQuery, invite.Code)
+			if err != nil {
+				return nil, fmt.Errorf("failed to use invite: %v", err)
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return nil, fmt.Errorf("failed to use invite: %v", err)
+			}
+			if affected == 0 {
+				return nil, fmt.Errorf("%w: this invite is no longer valid", model.ErrInvalid)
+			}
+		}
+
+		return insertMember(tx, model.Member{UserID: userID, ServerID: invite.ServerID})
+	})
+}
+
+func (r *InviteRepository) DeleteInvite(invite model.Invite) (*model.Invite, error) {
+	query := "DELETE FROM server_invites WHERE code = $1 RETURNING code"
+
+	err := r.db.QueryRow(query, invite.Code).Scan(&invite.Code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invite %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to delete invite: %v", err)
+	}
+
+	return &invite, nil
+}
+
+// RegenerateDefaultInvite replaces the default invite code of a server, which
+// invalidates the previous one.
+func (r *InviteRepository) RegenerateDefaultInvite(serverID string) (*model.Invite, error) {
+	query := `
+		UPDATE servers
+		SET invite_code = gen_random_uuid(), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING invite_code::text, id, user_id::text, created_at
+	`
+
+	invite := &model.Invite{Default: true}
+	err := r.db.QueryRow(query, serverID).Scan(
+		&invite.Code,
+		&invite.ServerID,
+		&invite.CreatorID,
+		&invite.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("server %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to regenerate invite: %v", err)
+	}
+
+	return invite, nil
+}
+
+func scanInvite(row interface{ Scan(dest ...any) error }, invite *model.Invite) error {
+	return row.Scan(
+		&invite.Code,
+		&invite.ServerID,
+		&invite.CreatorID,
+		&invite.MaxUses,
+		&invite.Uses,
+		&invite.ExpiresAt,
+		&invite.Default,
+		&invite.CreatedAt,
+	)
+}
@@ -5,8 +5,14 @@ import (
 	"fmt"
 
 	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/app/repository/helper"
 )
 
+const memberColumns = `
+	m.id, m.user_id, m.server_id, m.created_at, m.updated_at,
+	COALESCE((SELECT json_agg(mr.role_id) FROM member_roles mr WHERE mr.member_id = m.id), '[]')
+`
+
 type MemberRepository struct {
 	db *sql.DB
 }
@@ -16,16 +22,19 @@ func NewMemberRepository(db *sql.DB) *MemberRepository {
 }
 
 func (r *MemberRepository) CreateMember(member model.Member) (*model.Member, error) {
-	query := "INSERT INTO members (role, user_id, server_id) VALUES ($1,$2,$3) RETURNING id, created_at, updated_at"
+	return insertMember(r.db, member)
+}
 
-	stmt, err := r.db.Prepare(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %v", err)
-	}
-	defer stmt.Close()
+// insertMember adds member to its server through db, which is either the
+// connection pool or a transaction. It fails with ErrConflict when the user
+// is already a member of the server.
+func insertMember(db interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, member model.Member) (*model.Member, error) {
+	query := "INSERT INTO members (user_id, server_id) VALUES ($1,$2) RETURNING id, created_at, updated_at"
 
-	err = stmt.QueryRow(
-		member.Role,
+	err := db.QueryRow(
+		query,
 		member.UserID,
 		member.ServerID,
 	).Scan(
@@ -34,8 +43,150 @@ func (r *MemberRepository) CreateMember(member model.Member) (*model.Member, err
 		&member.UpdatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute statement: %v", err)
+		if helper.IsUniqueViolation(err, "members_user_server_idx") {
+			return nil, fmt.Errorf("%w: you are already a member of this server", model.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to create member: %v", err)
 	}
 
+	member.RoleIDs = []string{}
 	return &member, nil
 }
+
+func (r *MemberRepository) FindMemberByChannel(userID, channelID string) (*model.Member, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM members m
+		JOIN channels c ON c.server_id = m.server_id
+		WHERE m.user_id = $1 AND c.id = $2
+	`, memberColumns)
+
+	return r.findMember(query, userID, channelID)
+}
+
+func (r *MemberRepository) FindMemberByID(id string) (*model.Member, error) {
+	query := fmt.Sprintf("SELECT %s FROM members m WHERE m.id = $1", memberColumns)
+
+	return r.findMember(query, id)
+}
+
+func (r *MemberRepository) FindMemberByServer(userID, serverID string) (*model.Member, error) {
+	query := fmt.Sprintf("SELECT %s FROM members m WHERE m.user_id = $1 AND m.server_id = $2", memberColumns)
+
+	return r.findMember(query, userID, serverID)
+}
+
+// FindMembersByServer lists the members of a server in the order they joined.
+func (r *MemberRepository) FindMembersByServer(serverID string) ([]model.Member, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM members m
+		WHERE m.server_id = $1
+		ORDER BY m.created_at ASC, m.id ASC
+	`, memberColumns)
+
+	rows, err := r.db.Query(query, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %v", err)
+	}
+	defer rows.Close()
+
+	members := []model.Member{}
+	for rows.Next() {
+		var member model.Member
+		err := scanMember(rows, &member)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member: %v", err)
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %v", err)
+	}
+
+	return members, nil
+}
+
+func (r *MemberRepository) CountMembersByServer(serverID string) (int, error) {
+	query := "SELECT COUNT(*) FROM members WHERE server_id = $1"
+
+	var count int
+	if err := r.db.QueryRow(query, serverID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count members: %v", err)
+	}
+
+	return count, nil
+}
+
+func (r *MemberRepository) DeleteMember(member model.Member) (*model.Member, error) {
+	query := "DELETE FROM members WHERE id = $1 RETURNING id"
+
+	err := r.db.QueryRow(query, member.ID).Scan(&member.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("member %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to delete member: %v", err)
+	}
+
+	return &member, nil
+}
+
+func (r *MemberRepository) BanMember(member model.Member, bannedBy string) (*model.Member, error) {
+	result, err := helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Member, error) {
+		banQuery := `
+			INSERT INTO server_bans (server_id, user_id, banned_by)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (server_id, user_id) DO NOTHING
+		`
+		if _, err := tx.Exec(banQuery, member.ServerID, member.UserID, bannedBy); err != nil {
+			return nil, fmt.Errorf("failed to create ban: %v", err)
+		}
+
+		if _, err := tx.Exec("DELETE FROM members WHERE id = $1", member.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete member: %v", err)
+		}
+
+		return &member, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to ban member: %v", err)
+	}
+
+	return result, nil
+}
+
+func (r *MemberRepository) IsBanned(userID, serverID string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM server_bans WHERE user_id = $1 AND server_id = $2)"
+
+	var banned bool
+	if err := r.db.QueryRow(query, userID, serverID).Scan(&banned); err != nil {
+		return false, fmt.Errorf("failed to check ban: %v", err)
+	}
+
+	return banned, nil
+}
+
+func (r *MemberRepository) findMember(query string, args ...any) (*model.Member, error) {
+	member := &model.Member{}
+	err := scanMember(r.db.QueryRow(query, args...), member)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("member %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch member: %v", err)
+	}
+
+	return member, nil
+}
+
+func scanMember(row interface{ Scan(dest ...any) error }, member *model.Member) error {
+	return row.Scan(
+		&member.ID,
+		&member.UserID,
+		&member.ServerID,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+		helper.JSON(&member.RoleIDs),
+	)
+}
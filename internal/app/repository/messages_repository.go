@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+const messageColumns = `
+	id, type, content, user_id, COALESCE(member_id::text, ''),
+	COALESCE(channel_id::text, ''), COALESCE(conversation_id::text, ''),
+	deleted, edited_at, created_at, updated_at
+`
+
+type MessageRepository struct {
+	db *sql.DB
+}
+
+func NewMessageRepository(db *sql.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+func (r *MessageRepository) CreateMessage(message model.Message) (*model.Message, error) {
+	query := `
+		INSERT INTO messages (type, content, user_id, member_id, channel_id, conversation_id)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, '')::uuid, NULLIF($6, '')::uuid)
+		RETURNING id, deleted, created_at, updated_at
+	`
+
+	if message.Type == "" {
+		message.Type = model.DEFAULT
+	}
+
+	err := r.db.QueryRow(
+		query,
+		message.Type,
+		message.Content,
+		message.UserID,
+		message.MemberID,
+		message.ChannelID,
+		message.ConversationID,
+	).Scan(
+		&message.ID,
+		&message.Deleted,
+		&message.CreatedAt,
+		&message.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message: %v", err)
+	}
+
+	return &message, nil
+}
+
+func (r *MessageRepository) FindMessageByID(id string) (*model.Message, error) {
+	query := fmt.Sprintf("SELECT %s FROM messages WHERE id = $1", messageColumns)
+
+	message := &model.Message{}
+	err := scanMessage(r.db.QueryRow(query, id), message)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("message %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch message: %v", err)
+	}
+
+	return message, nil
+}
+
+// FindMessagesBefore returns up to limit messages older than cursor, or the
+// latest messages when cursor is nil, in ascending order.
+func (r *MessageRepository) FindMessagesBefore(field, targetID string, cursor *model.Message, limit int) ([]model.Message, error) {
+	query := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT %s FROM messages
+			WHERE %s = $1 AND deleted = FALSE
+			AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+			ORDER BY created_at DESC, id DESC
+			LIMIT $4
+		) page
+		ORDER BY created_at ASC, id ASC
+	`, messageColumns, field)
+
+	createdAt, id := cursorArgs(cursor)
+	return r.queryMessages(query, targetID, createdAt, id, limit)
+}
+
+// FindMessagesAfter returns up to limit messages newer than cursor in
+// ascending order.
+func (r *MessageRepository) FindMessagesAfter(field, targetID string, cursor *model.Message, limit int) ([]model.Message, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM messages
+		WHERE %s = $1 AND deleted = FALSE
+		AND ($2::timestamptz IS NULL OR (created_at, id) > ($2, $3::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $4
+	`, messageColumns, field)
+
+	createdAt, id := cursorArgs(cursor)
+	return r.queryMessages(query, targetID, createdAt, id, limit)
+}
+
+func cursorArgs(cursor *model.Message) (any, any) {
+	if cursor == nil {
+		return nil, nil
+	}
+	return cursor.CreatedAt, cursor.ID
+}
+
+func (r *MessageRepository) queryMessages(query string, args ...any) ([]model.Message, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %v", err)
+	}
+	defer rows.Close()
+
+	messages := []model.Message{}
+	for rows.Next() {
+		var message model.Message
+		err := scanMessage(rows, &message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %v", err)
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %v", err)
+	}
+
+	return messages, nil
+}
+
+func (r *MessageRepository) UpdateMessageContent(message model.Message) (*model.Message, error) {
+	query := fmt.Sprintf(`
+		UPDATE messages
+		SET content = $1, edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND deleted = FALSE
+		RETURNING %s
+	`, messageColumns)
+
+	updated := &model.Message{}
+	err := scanMessage(r.db.QueryRow(query, message.Content, message.ID), updated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("message %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update message: %v", err)
+	}
+
+	return updated, nil
+}
+
+func (r *MessageRepository) SoftDeleteMessage(message model.Message) (*model.Message, error) {
+	query := fmt.Sprintf(`
+		UPDATE messages
+		SET deleted = TRUE, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted = FALSE
+		RETURNING %s
+	`, messageColumns)
+
+	deleted := &model.Message{}
+	err := scanMessage(r.db.QueryRow(query, message.ID), deleted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("message %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to delete message: %v", err)
+	}
+
+	return deleted, nil
+}
+
+func scanMessage(row interface{ Scan(dest ...any) error }, message *model.Message) error {
+	return row.Scan(
+		&message.ID,
+		&message.Type,
+		&message.Content,
+		&message.UserID,
+		&message.MemberID,
+		&message.ChannelID,
+		&message.ConversationID,
+		&message.Deleted,
+		&message.EditedAt,
+		&message.CreatedAt,
+		&message.UpdatedAt,
+	)
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+const readStateColumns = `
+	user_id, COALESCE(channel_id::text, ''), COALESCE(conversation_id::text, ''),
+	last_message_id, updated_at
+`
+
+type ReadStateRepository struct {
+	db *sql.DB
+}
+
+func NewReadStateRepository(db *sql.DB) *ReadStateRepository {
+	return &ReadStateRepository{db: db}
+}
+
+// UpsertReadState moves the user's read state in the channel or conversation
+// of lastMessage up to it. Acks for a message older than the current one
+// leave the read state untouched and return it as is.
+func (r *ReadStateRepository) UpsertReadState(userID string, lastMessage model.Message) (*model.ReadState, error) {
+	field, targetID := "channel_id", lastMessage.ChannelID
+	if targetID == "" {
+		field, targetID = "conversation_id", lastMessage.ConversationID
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO read_states (user_id, %[1]s, last_message_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, %[1]s) WHERE %[1]s IS NOT NULL
+		DO UPDATE SET last_message_id = EXCLUDED.last_message_id, updated_at = CURRENT_TIMESTAMP
+		WHERE ($4::timestamptz, $3::uuid) > (
+			SELECT created_at, id FROM messages WHERE id = read_states.last_message_id
+		)
+		RETURNING %[2]s
+	`, field, readStateColumns)
+
+	state := &model.ReadState{}
+	err := scanReadState(r.db.QueryRow(query, userID, targetID, lastMessage.ID, lastMessage.CreatedAt), state)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return r.FindReadState(userID, field, targetID)
+		}
+		return nil, fmt.Errorf("failed to update read state: %v", err)
+	}
+
+	return state, nil
+}
+
+func (r *ReadStateRepository) FindReadState(userID, field, targetID string) (*model.ReadState, error) {
+	query := fmt.Sprintf("SELECT %s FROM read_states WHERE user_id = $1 AND %s = $2", readStateColumns, field)
+
+	state := &model.ReadState{}
+	err := scanReadState(r.db.QueryRow(query, userID, targetID), state)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("read state %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch read state: %v", err)
+	}
+
+	return state, nil
+}
+
+func scanReadState(row interface{ Scan(dest ...any) error }, state *model.ReadState) error {
+	return row.Scan(
+		&state.UserID,
+		&state.ChannelID,
+		&state.ConversationID,
+		&state.LastMessageID,
+		&state.UpdatedAt,
+	)
+}
+
+// unreadCountsJoin joins rc.unread_count and rc.mention_count for the channel
+// or conversation whose id is targetColumn, as seen by the user bound to
+// userParam. Only messages from others after the user's read state count,
+// mentions are matched on model.MentionToken.
+func unreadCountsJoin(field, targetColumn, userParam string) string {
+	return fmt.Sprintf(`
+		LEFT JOIN read_states rs ON rs.user_id = %[3]s AND rs.%[1]s = %[2]s
+		LEFT JOIN messages lr ON lr.id = rs.last_message_id
+		CROSS JOIN LATERAL (
+			SELECT
+				COUNT(*) AS unread_count,
+				COUNT(*) FILTER (WHERE m.content LIKE %[4]s) AS mention_count
+			FROM messages m
+			WHERE m.%[1]s = %[2]s AND m.deleted = FALSE
+			AND m.type = 'DEFAULT' AND m.user_id <> %[3]s
+			AND (lr.id IS NULL OR (m.created_at, m.id) > (lr.created_at, lr.id))
+		) rc
+	`, field, targetColumn, userParam, mentionPattern(userParam))
+}
+
+// mentionPattern is a LIKE pattern matching contents that hold the
+// model.MentionToken of the user bound to userParam.
+func mentionPattern(userParam string) string {
+	prefix, suffix, _ := strings.Cut(model.MentionToken("\x00"), "\x00")
+	return fmt.Sprintf("'%%' || %s || %s || %s || '%%'", likeLiteral(prefix), userParam, likeLiteral(suffix))
+}
+
+// likeLiteral quotes s as an SQL string that matches itself in a LIKE
+// pattern.
+func likeLiteral(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "'", "''").Replace(s)
+	return "'" + s + "'"
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/internal/app/repository/helper"
+)
+
+const roleColumns = "id, server_id, name, color, position, permissions, is_default, created_at, updated_at"
+
+type RoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// CreateRole adds a role right above the default role of its server, moving
+// the other roles up.
+func (r *RoleRepository) CreateRole(role model.Role) (*model.Role, error) {
+	return helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Role, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", role.ServerID); err != nil {
+			return nil, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		shiftQuery := `
+			UPDATE roles SET position = position + 1, updated_at = CURRENT_TIMESTAMP
+			WHERE server_id = $1 AND NOT is_default
+		`
+		if _, err := tx.Exec(shiftQuery, role.ServerID); err != nil {
+			return nil, fmt.Errorf("failed to create role: %v", err)
+		}
+
+		query := fmt.Sprintf(`
+			INSERT INTO roles (server_id, name, color, position, permissions)
+			VALUES ($1, $2, $3, 1, $4)
+			RETURNING %s
+		`, roleColumns)
+
+		created := &model.Role{}
+		err := scanRole(tx.QueryRow(query, role.ServerID, role.Name, role.Color, role.Permissions), created)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create role: %v", err)
+		}
+
+		return created, nil
+	})
+}
+
+func (r *RoleRepository) FindRoleByID(id string) (*model.Role, error) {
+	query := fmt.Sprintf("SELECT %s FROM roles WHERE id = $1", roleColumns)
+
+	role := &model.Role{}
+	err := scanRole(r.db.QueryRow(query, id), role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("role %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch role: %v", err)
+	}
+
+	return role, nil
+}
+
+// FindRolesByServer lists the roles of a server from the highest to the
+// default role.
+func (r *RoleRepository) FindRolesByServer(serverID string) ([]model.Role, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM roles
+		WHERE server_id = $1
+		ORDER BY position DESC, created_at ASC, id ASC
+	`, roleColumns)
+
+	return r.queryRoles(query, serverID)
+}
+
+// FindRolesByMember lists the roles a member holds, the default role of its
+// server included, from the highest.
+func (r *RoleRepository) FindRolesByMember(memberID string) ([]model.Role, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM roles
+		WHERE server_id = (SELECT server_id FROM members WHERE id = $1)
+		AND (is_default OR id IN (SELECT role_id FROM member_roles WHERE member_id = $1))
+		ORDER BY position DESC, created_at ASC, id ASC
+	`, roleColumns)
+
+	return r.queryRoles(query, memberID)
+}
+
+func (r *RoleRepository) queryRoles(query string, args ...any) ([]model.Role, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %v", err)
+	}
+	defer rows.Close()
+
+	roles := []model.Role{}
+	for rows.Next() {
+		var role model.Role
+		err := scanRole(rows, &role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role: %v", err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %v", err)
+	}
+
+	return roles, nil
+}
+
+// UpdateRole changes the fields of updateRolePayload that are set.
+func (r *RoleRepository) UpdateRole(id string, updateRolePayload model.UpdateRolePayload) (*model.Role, error) {
+	query := fmt.Sprintf(`
+		UPDATE roles
+		SET
+			name = COALESCE($1, name),
+			color = COALESCE($2, color),
+			permissions = COALESCE($3, permissions),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING %s
+	`, roleColumns)
+
+	role := &model.Role{}
+	err := scanRole(r.db.QueryRow(
+		query,
+		updateRolePayload.Name,
+		updateRolePayload.Color,
+		updateRolePayload.Permissions,
+		id,
+	), role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("role %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update role: %v", err)
+	}
+
+	return role, nil
+}
+
+// DeleteRole removes a role from the server and from the members holding it,
+// moving the roles above it down.
+func (r *RoleRepository) DeleteRole(role model.Role) (*model.Role, error) {
+	return helper.ExecWithTx(r.db, func(tx *sql.Tx) (*model.Role, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", role.ServerID); err != nil {
+			return nil, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		query := fmt.Sprintf("DELETE FROM roles WHERE id = $1 AND NOT is_default RETURNING %s", roleColumns)
+
+		deleted := &model.Role{}
+		err := scanRole(tx.QueryRow(query, role.ID), deleted)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("role %w", model.ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to delete role: %v", err)
+		}
+
+		shiftQuery := `
+			UPDATE roles SET position = position - 1, updated_at = CURRENT_TIMESTAMP
+			WHERE server_id = $1 AND position > $2
+		`
+		if _, err := tx.Exec(shiftQuery, deleted.ServerID, deleted.Position); err != nil {
+			return nil, fmt.Errorf("failed to delete role: %v", err)
+		}
+
+		return deleted, nil
+	})
+}
+
+// ReorderRoles sets the positions of the roles of a server, the default role
+// aside, from roleIDs listing each of them exactly once from the highest.
+func (r *RoleRepository) ReorderRoles(serverID string, roleIDs []string) error {
+	_, err := helper.ExecWithTx(r.db, func(tx *sql.Tx) (struct{}, error) {
+		if _, err := tx.Exec("SELECT 1 FROM servers WHERE id = $1 FOR UPDATE", serverID); err != nil {
+			return struct{}{}, fmt.Errorf("failed to lock server: %v", err)
+		}
+
+		var count int
+		countQuery := "SELECT COUNT(*) FROM roles WHERE server_id = $1 AND NOT is_default"
+		if err := tx.QueryRow(countQuery, serverID).Scan(&count); err != nil {
+			return struct{}{}, fmt.Errorf("failed to count roles: %v", err)
+		}
+
+		query := `
+			UPDATE roles r
+			SET position = $3 - ordered.position + 1, updated_at = CURRENT_TIMESTAMP
+			FROM unnest($2::text[]) WITH ORDINALITY AS ordered(id, position)
+			WHERE r.id = ordered.id::uuid AND r.server_id = $1 AND NOT r.is_default
+		`
+
+		result, err := tx.Exec(query, serverID, roleIDs, len(roleIDs))
+		if err != nil {
+			return struct{}{}, fmt.Errorf("failed to reorder roles: %v", err)
+		}
+
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return struct{}{}, fmt.Errorf("failed to reorder roles: %v", err)
+		}
+
+		if int(updated) != len(roleIDs) || len(roleIDs) != count {
+			return struct{}{}, fmt.Errorf("%w: role_ids must list every role of the server but the default one once", model.ErrInvalid)
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (r *RoleRepository) AddMemberRole(memberID, roleID string) error {
+	query := `
+		INSERT INTO member_roles (member_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT (member_id, role_id) DO NOTHING
+	`
+
+	if _, err := r.db.Exec(query, memberID, roleID); err != nil {
+		return fmt.Errorf("failed to add member role: %v", err)
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) RemoveMemberRole(memberID, roleID string) error {
+	query := "DELETE FROM member_roles WHERE member_id = $1 AND role_id = $2"
+
+	result, err := r.db.Exec(query, memberID, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member role: %v", err)
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to remove member role: %v", err)
+	}
+
+	if removed == 0 {
+		return fmt.Errorf("member role %w", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanRole(row interface{ Scan(dest ...any) error }, role *model.Role) error {
+	return row.Scan(
+		&role.ID,
+		&role.ServerID,
+		&role.Name,
+		&role.Color,
+		&role.Position,
+		&role.Permissions,
+		&role.Default,
+		&role.CreatedAt,
+		&role.UpdatedAt,
+	)
+}
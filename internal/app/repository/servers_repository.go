@@ -8,6 +8,10 @@ import (
 	"github.com/razaq-himawan/chat-app-api/internal/app/repository/helper"
 )
 
+const serverColumns = `
+	s.id, s.name, COALESCE(s.icon_url, ''), s.invite_code, s.user_id, s.created_at, s.updated_at
+`
+
 type ServerRepository struct {
 	db *sql.DB
 }
@@ -33,16 +37,21 @@ func (r *ServerRepository) CreateServerWithDefaults(server model.ServerModel) (*
 			return nil, fmt.Errorf("failed to create server: %v", err)
 		}
 
-		memberQuery := "INSERT INTO members (role, user_id, server_id) VALUES ($1,$2,$3) RETURNING *"
-		var member model.Member
+		roleQuery := fmt.Sprintf("INSERT INTO roles (server_id, name, permissions, is_default) VALUES ($1,$2,$3,TRUE) RETURNING %s", roleColumns)
+		var role model.Role
+		err = scanRole(tx.QueryRow(roleQuery, server.ID, "@everyone", model.DefaultPermissions), &role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create role: %v", err)
+		}
+
+		memberQuery := "INSERT INTO members (user_id, server_id) VALUES ($1,$2) RETURNING id, user_id, server_id, created_at, updated_at"
+		member := model.Member{RoleIDs: []string{}}
 		err = tx.QueryRow(
 			memberQuery,
-			model.ADMIN,
 			server.UserID,
 			server.ID,
 		).Scan(
 			&member.ID,
-			&member.Role,
 			&member.UserID,
 			&member.ServerID,
 			&member.CreatedAt,
@@ -52,7 +61,7 @@ func (r *ServerRepository) CreateServerWithDefaults(server model.ServerModel) (*
 			return nil, fmt.Errorf("failed to create member: %v", err)
 		}
 
-		channelQuery := "INSERT INTO channels (name, type, user_id, server_id) VALUES ($1,$2,$3,$4) RETURNING *"
+		channelQuery := "INSERT INTO channels (name, type, user_id, server_id) VALUES ($1,$2,$3,$4) RETURNING id, name, type, user_id, server_id, position, created_at, updated_at"
 		var channel model.Channel
 		err = tx.QueryRow(
 			channelQuery,
@@ -66,6 +75,7 @@ func (r *ServerRepository) CreateServerWithDefaults(server model.ServerModel) (*
 			&channel.Type,
 			&channel.UserID,
 			&channel.ServerID,
+			&channel.Position,
 			&channel.CreatedAt,
 			&channel.UpdatedAt,
 		)
@@ -74,6 +84,7 @@ func (r *ServerRepository) CreateServerWithDefaults(server model.ServerModel) (*
 		}
 
 		server.Members = []model.Member{member}
+		server.Roles = []model.Role{role}
 		server.Channel = []model.Channel{channel}
 
 		return &server, nil
@@ -84,3 +95,102 @@ func (r *ServerRepository) CreateServerWithDefaults(server model.ServerModel) (*
 
 	return result, nil
 }
+
+func (r *ServerRepository) FindServerByID(id string) (*model.ServerModel, error) {
+	query := fmt.Sprintf("SELECT %s FROM servers s WHERE s.id = $1", serverColumns)
+
+	server := &model.ServerModel{}
+	err := scanServer(r.db.QueryRow(query, id), server)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("server %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch server: %v", err)
+	}
+
+	return server, nil
+}
+
+// FindServersByUser lists the servers userID is a member of, in the order
+// they joined them.
+func (r *ServerRepository) FindServersByUser(userID string) ([]model.ServerModel, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM servers s
+		JOIN members m ON m.server_id = s.id
+		WHERE m.user_id = $1
+		ORDER BY m.created_at ASC, s.id ASC
+	`, serverColumns)
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch servers: %v", err)
+	}
+	defer rows.Close()
+
+	servers := []model.ServerModel{}
+	for rows.Next() {
+		var server model.ServerModel
+		if err := scanServer(rows, &server); err != nil {
+			return nil, fmt.Errorf("failed to scan server: %v", err)
+		}
+		servers = append(servers, server)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch servers: %v", err)
+	}
+
+	return servers, nil
+}
+
+// UpdateServer sets the fields of the payload that are not nil, an empty icon
+// url clearing the icon.
+func (r *ServerRepository) UpdateServer(id string, updateServerPayload model.UpdateServerPayload) (*model.ServerModel, error) {
+	query := fmt.Sprintf(`
+		UPDATE servers s
+		SET
+			name = COALESCE($1, s.name),
+			icon_url = CASE WHEN $2::text IS NULL THEN s.icon_url ELSE NULLIF($2, '') END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE s.id = $3
+		RETURNING %s
+	`, serverColumns)
+
+	server := &model.ServerModel{}
+	err := scanServer(r.db.QueryRow(query, updateServerPayload.Name, updateServerPayload.IconURL, id), server)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("server %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update server: %v", err)
+	}
+
+	return server, nil
+}
+
+// DeleteServer removes a server along with its members, channels and their
+// messages.
+func (r *ServerRepository) DeleteServer(server model.ServerModel) (*model.ServerModel, error) {
+	query := "DELETE FROM servers WHERE id = $1 RETURNING id"
+
+	err := r.db.QueryRow(query, server.ID).Scan(&server.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("server %w", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to delete server: %v", err)
+	}
+
+	return &server, nil
+}
+
+func scanServer(row interface{ Scan(dest ...any) error }, server *model.ServerModel) error {
+	return row.Scan(
+		&server.ID,
+		&server.Name,
+		&server.IconURL,
+		&server.InviteCode,
+		&server.UserID,
+		&server.CreatedAt,
+		&server.UpdatedAt,
+	)
+}
@@ -98,7 +98,8 @@ func (r *UserRepository) FindUserByFieldWithProfile(field, value string) (*model
 	query := fmt.Sprintf(`
 		SELECT 
 			u.id, u.username, u.email, u.created_at, u.updated_at,
-			p.id, p.user_id, p.name, p.image_url, p.banner_url, p.bio, p.status, p.created_at, p.updated_at
+			p.id, p.user_id, p.name, p.image_url, p.banner_url, p.bio, p.status,
+			COALESCE(p.manual_status::text, ''), p.created_at, p.updated_at
 		FROM users u
 		LEFT JOIN profiles p ON u.id = p.user_id
 		WHERE u.%s = $1
@@ -119,6 +120,7 @@ func (r *UserRepository) FindUserByFieldWithProfile(field, value string) (*model
 		&profile.BannerURL,
 		&profile.Bio,
 		&profile.Status,
+		&profile.ManualStatus,
 		&profile.CreatedAt,
 		&profile.UpdatedAt,
 	)
@@ -141,9 +143,9 @@ func (r *UserRepository) FindUserByFieldWithProfile(field, value string) (*model
 func (r *UserRepository) UpdateUserProfile(profile model.UserProfile) (*model.UserProfile, error) {
 	query := `
 		UPDATE profiles
-		SET name = $1, image_url = $2, banner_url = $3, bio = $4, status = $5
+		SET name = $1, image_url = $2, banner_url = $3, bio = $4, manual_status = NULLIF($5, '')::PROFILESTATUS
 		WHERE user_id = $6
-		RETURNING id, created_at, updated_at
+		RETURNING id, status, created_at, updated_at
 	`
 
 	err := r.db.QueryRow(
@@ -152,10 +154,11 @@ func (r *UserRepository) UpdateUserProfile(profile model.UserProfile) (*model.Us
 		profile.ImageURL,
 		profile.BannerURL,
 		profile.Bio,
-		profile.Status,
+		profile.ManualStatus,
 		profile.UserID,
 	).Scan(
 		&profile.ID,
+		&profile.Status,
 		&profile.CreatedAt,
 		&profile.UpdatedAt,
 	)
@@ -169,6 +172,76 @@ func (r *UserRepository) UpdateUserProfile(profile model.UserProfile) (*model.Us
 	return &profile, nil
 }
 
+func (r *UserRepository) UpdateUserStatus(userID string, status model.ProfileStatus) error {
+	query := "UPDATE profiles SET status = $1 WHERE user_id = $2"
+
+	result, err := r.db.Exec(query, status, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %v", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("profile %w", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ResetUserStatuses marks every user offline. Connections do not survive a
+// restart, so on a single instance any other status left in the table is
+// stale. It must not run while other instances hold connections.
+func (r *UserRepository) ResetUserStatuses() error {
+	query := "UPDATE profiles SET status = 'OFFLINE' WHERE status <> 'OFFLINE'"
+
+	if _, err := r.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to reset user statuses: %v", err)
+	}
+
+	return nil
+}
+
+// FindRelatedUserIDs returns every other user who shares a server or a
+// conversation with userID.
+func (r *UserRepository) FindRelatedUserIDs(userID string) ([]string, error) {
+	query := `
+		SELECT other.user_id FROM members self
+		JOIN members other ON other.server_id = self.server_id
+		WHERE self.user_id = $1 AND other.user_id <> $1
+		UNION
+		SELECT other.user_id FROM conversation_participants self
+		JOIN conversation_participants other ON other.conversation_id = self.conversation_id
+		WHERE self.user_id = $1 AND other.user_id <> $1
+		UNION
+		SELECT CASE WHEN member_one_id = $1 THEN member_two_id ELSE member_one_id END
+		FROM conversations
+		WHERE type = 'DM' AND (member_one_id = $1 OR member_two_id = $1)
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch related users: %v", err)
+	}
+	defer rows.Close()
+
+	userIDs := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan related user: %v", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch related users: %v", err)
+	}
+
+	return userIDs, nil
+}
+
 func (r *UserRepository) DeleteUser(user model.User) (*model.User, error) {
 	query := "DELETE FROM users WHERE id = $1 RETURNING id"
 
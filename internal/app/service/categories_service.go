@@ -0,0 +1,75 @@
+package service
+
+import (
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type CategoryService struct {
+	categoryRepo model.CategoryRepository
+	permissions  *PermissionResolver
+}
+
+func NewCategoryService(categoryRepo model.CategoryRepository, permissions *PermissionResolver) *CategoryService {
+	return &CategoryService{categoryRepo: categoryRepo, permissions: permissions}
+}
+
+// CreateCategory adds a category at the end of the server, which only members
+// allowed to manage channels may do.
+func (s *CategoryService) CreateCategory(userID, serverID string, createCategoryPayload model.CreateCategoryPayload) (*model.Category, error) {
+	if err := s.checkManage(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	return s.categoryRepo.CreateCategory(model.Category{
+		Name:     createCategoryPayload.Name,
+		ServerID: serverID,
+	})
+}
+
+func (s *CategoryService) UpdateCategory(userID, categoryID string, updateCategoryPayload model.UpdateCategoryPayload) (*model.Category, error) {
+	category, err := s.categoryRepo.FindCategoryByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkManage(userID, category.ServerID); err != nil {
+		return nil, err
+	}
+
+	category.Name = updateCategoryPayload.Name
+	return s.categoryRepo.UpdateCategory(*category)
+}
+
+// DeleteCategory deletes a category but keeps its channels, which move to the
+// end of the channels without a category. The category is returned with them.
+func (s *CategoryService) DeleteCategory(userID, categoryID string) (*model.Category, error) {
+	category, err := s.categoryRepo.FindCategoryByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkManage(userID, category.ServerID); err != nil {
+		return nil, err
+	}
+
+	return s.categoryRepo.DeleteCategory(*category)
+}
+
+// ReorderCategories moves the categories of a server into the order given by
+// the payload and returns them in that order.
+func (s *CategoryService) ReorderCategories(userID, serverID string, reorderCategoriesPayload model.ReorderCategoriesPayload) ([]model.Category, error) {
+	if err := s.checkManage(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryRepo.ReorderCategories(serverID, reorderCategoriesPayload.CategoryIDs); err != nil {
+		return nil, err
+	}
+
+	return s.categoryRepo.FindCategoriesByServer(serverID)
+}
+
+func (s *CategoryService) checkManage(userID, serverID string) error {
+	_, err := s.permissions.Require(userID, serverID, model.PermManageChannels, "manage channels")
+	return err
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type ChannelService struct {
+	channelRepo  model.ChannelRepository
+	categoryRepo model.CategoryRepository
+	roleRepo     model.RoleRepository
+	permissions  *PermissionResolver
+}
+
+func NewChannelService(channelRepo model.ChannelRepository, categoryRepo model.CategoryRepository, roleRepo model.RoleRepository, permissions *PermissionResolver) *ChannelService {
+	return &ChannelService{channelRepo: channelRepo, categoryRepo: categoryRepo, roleRepo: roleRepo, permissions: permissions}
+}
+
+// GetChannel returns the channel when userID may view it.
+func (s *ChannelService) GetChannel(userID, channelID string) (*model.Channel, error) {
+	channel, _, err := s.permissions.RequireChannel(userID, channelID, model.PermViewChannels, "view this channel")
+	return channel, err
+}
+
+// GetServerChannels lists the channels of a server the user may view, with
+// their unread and mention counts.
+func (s *ChannelService) GetServerChannels(userID, serverID string) ([]model.Channel, error) {
+	permissions, err := s.permissions.Resolve(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := s.channelRepo.FindChannelsByServer(serverID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.permissions.VisibleChannels(permissions, channels)
+}
+
+// CreateChannel adds a channel at the end of its category, which only
+// members allowed to manage channels may do.
+func (s *ChannelService) CreateChannel(userID, serverID string, createChannelPayload model.CreateChannelPayload) (*model.Channel, error) {
+	if _, err := s.checkManage(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCategory(serverID, createChannelPayload.CategoryID); err != nil {
+		return nil, err
+	}
+
+	return s.channelRepo.CreateChannel(model.Channel{
+		Name:       createChannelPayload.Name,
+		Type:       createChannelPayload.Type,
+		UserID:     userID,
+		ServerID:   serverID,
+		CategoryID: createChannelPayload.CategoryID,
+	})
+}
+
+func (s *ChannelService) UpdateChannel(userID, channelID string, updateChannelPayload model.UpdateChannelPayload) (*model.Channel, error) {
+	channel, err := s.channelRepo.FindChannelByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.checkManage(userID, channel.ServerID); err != nil {
+		return nil, err
+	}
+
+	channel.Name = updateChannelPayload.Name
+	return s.channelRepo.UpdateChannel(*channel)
+}
+
+// DeleteChannel deletes a channel along with its messages. The last text
+// channel of a server cannot be deleted. It returns the members who could
+// view the channel, which its overwrites no longer tell once it is gone.
+func (s *ChannelService) DeleteChannel(userID, channelID string) (*model.Channel, []model.Member, error) {
+	channel, err := s.channelRepo.FindChannelByID(channelID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.checkManage(userID, channel.ServerID); err != nil {
+		return nil, nil, err
+	}
+
+	viewers, err := s.permissions.ChannelViewers(channel.ServerID, []model.Channel{*channel})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deleted, err := s.channelRepo.DeleteChannel(*channel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deleted, viewers[channel.ID], nil
+}
+
+// ReorderChannels moves the channels of a category into the order given by
+// the payload and returns them in that order.
+func (s *ChannelService) ReorderChannels(userID, serverID string, reorderChannelsPayload model.ReorderChannelsPayload) ([]model.Channel, error) {
+	permissions, err := s.checkManage(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID := reorderChannelsPayload.CategoryID
+	if err := s.checkCategory(serverID, categoryID); err != nil {
+		return nil, err
+	}
+
+	if err := s.channelRepo.ReorderChannels(serverID, categoryID, reorderChannelsPayload.ChannelIDs); err != nil {
+		return nil, err
+	}
+
+	return s.findChannelsInCategories(permissions, categoryID)
+}
+
+// MoveChannel moves a channel to another category or position. It returns
+// the channels of the categories it left and joined, whose positions may
+// have changed, in order. Both lists leave out the channels the user may
+// not view.
+func (s *ChannelService) MoveChannel(userID, channelID string, moveChannelPayload model.MoveChannelPayload) ([]model.Channel, error) {
+	channel, err := s.channelRepo.FindChannelByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.checkManage(userID, channel.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID := moveChannelPayload.CategoryID
+	if err := s.checkCategory(channel.ServerID, categoryID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.channelRepo.MoveChannel(*channel, categoryID, moveChannelPayload.Position); err != nil {
+		return nil, err
+	}
+
+	return s.findChannelsInCategories(permissions, channel.CategoryID, categoryID)
+}
+
+// findChannelsInCategories lists the channels of the categories the member
+// may view, in order.
+func (s *ChannelService) findChannelsInCategories(permissions *model.MemberPermissions, categoryIDs ...string) ([]model.Channel, error) {
+	member := permissions.Member
+	channels, err := s.channelRepo.FindChannelsByServer(member.ServerID, member.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err = s.permissions.VisibleChannels(permissions, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []model.Channel{}
+	for _, channel := range channels {
+		if slices.Contains(categoryIDs, channel.CategoryID) {
+			found = append(found, channel)
+		}
+	}
+
+	return found, nil
+}
+
+// GetChannelOverwrites lists the role overwrites of a channel.
+func (s *ChannelService) GetChannelOverwrites(userID, channelID string) ([]model.ChannelOverwrite, error) {
+	if _, _, err := s.permissions.RequireChannel(userID, channelID, model.PermManageChannels, "manage channels"); err != nil {
+		return nil, err
+	}
+
+	return s.channelRepo.FindChannelOverwrites(channelID)
+}
+
+// SetChannelOverwrite creates or replaces the overwrite of a role in a
+// channel. It returns the members who can no longer view the channel.
+func (s *ChannelService) SetChannelOverwrite(userID, channelID, roleID string, setChannelOverwritePayload model.SetChannelOverwritePayload) (*model.ChannelOverwrite, []model.Member, error) {
+	channel, err := s.checkOverwrite(userID, channelID, roleID, setChannelOverwritePayload.Allow|setChannelOverwritePayload.Deny)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if setChannelOverwritePayload.Allow&setChannelOverwritePayload.Deny != 0 {
+		return nil, nil, fmt.Errorf("%w: a permission cannot be both allowed and denied", model.ErrInvalid)
+	}
+
+	overwrite, err := s.channelRepo.SetChannelOverwrite(model.ChannelOverwrite{
+		ChannelID: channel.ID,
+		RoleID:    roleID,
+		Allow:     setChannelOverwritePayload.Allow,
+		Deny:      setChannelOverwritePayload.Deny,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hidden, err := s.permissions.MembersLacking(channel, model.PermViewChannels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return overwrite, hidden, nil
+}
+
+// DeleteChannelOverwrite removes the overwrite of a role in a channel. It
+// returns the members who can no longer view the channel.
+func (s *ChannelService) DeleteChannelOverwrite(userID, channelID, roleID string) ([]model.Member, error) {
+	channel, err := s.checkOverwrite(userID, channelID, roleID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.channelRepo.DeleteChannelOverwrite(model.ChannelOverwrite{ChannelID: channel.ID, RoleID: roleID}); err != nil {
+		return nil, err
+	}
+
+	return s.permissions.MembersLacking(channel, model.PermViewChannels)
+}
+
+// checkOverwrite verifies that userID may change the overwrite of roleID in
+// a channel: the role has to belong to the server and sit below the highest
+// role of the user, and perm may only hold permissions the user has there.
+func (s *ChannelService) checkOverwrite(userID, channelID, roleID string, perm model.Permission) (*model.Channel, error) {
+	channel, actor, err := s.permissions.RequireChannel(userID, channelID, model.PermManageChannels, "manage channels")
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.FindRoleByID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.ServerID != channel.ServerID {
+		return nil, fmt.Errorf("role %w", model.ErrNotFound)
+	}
+
+	if !role.Default && !actor.Outranks(role.Position) {
+		return nil, fmt.Errorf("%w: you cannot manage a role equal to or above your highest role", model.ErrForbidden)
+	}
+
+	if err := checkGrant(actor, perm); err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// checkCategory makes sure a category, when one is given, belongs to the
+// server.
+func (s *ChannelService) checkCategory(serverID, categoryID string) error {
+	if categoryID == "" {
+		return nil
+	}
+
+	category, err := s.categoryRepo.FindCategoryByID(categoryID)
+	if err != nil {
+		return err
+	}
+
+	if category.ServerID != serverID {
+		return fmt.Errorf("category %w", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *ChannelService) checkManage(userID, serverID string) (*model.MemberPermissions, error) {
+	return s.permissions.Require(userID, serverID, model.PermManageChannels, "manage channels")
+}
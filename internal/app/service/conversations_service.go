@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type ConversationService struct {
+	conversationRepo model.ConversationRepository
+	userRepo         model.UserRepository
+	messageRepo      model.MessageRepository
+}
+
+func NewConversationService(conversationRepo model.ConversationRepository, userRepo model.UserRepository, messageRepo model.MessageRepository) *ConversationService {
+	return &ConversationService{conversationRepo: conversationRepo, userRepo: userRepo, messageRepo: messageRepo}
+}
+
+func (s *ConversationService) GetOrCreateConversation(userID string, createConversationPayload model.CreateConversationPayload) (*model.Conversation, error) {
+	otherID := createConversationPayload.UserID
+	if otherID == userID {
+		return nil, fmt.Errorf("%w: cannot start a conversation with yourself", model.ErrInvalid)
+	}
+
+	if _, err := s.userRepo.FindUserByField("id", otherID); err != nil {
+		return nil, fmt.Errorf("user %w", model.ErrNotFound)
+	}
+
+	memberOneID, memberTwoID := userID, otherID
+	if memberTwoID < memberOneID {
+		memberOneID, memberTwoID = memberTwoID, memberOneID
+	}
+
+	return s.conversationRepo.GetOrCreateConversation(model.Conversation{
+		MemberOneID: memberOneID,
+		MemberTwoID: memberTwoID,
+	})
+}
+
+func (s *ConversationService) GetConversation(userID, conversationID string) (*model.Conversation, error) {
+	conversation, err := s.conversationRepo.FindConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !conversation.HasParticipant(userID) {
+		return nil, fmt.Errorf("%w: you are not a participant of this conversation", model.ErrForbidden)
+	}
+
+	return conversation, nil
+}
+
+func (s *ConversationService) GetUserConversations(userID string) ([]model.Conversation, error) {
+	return s.conversationRepo.FindConversationsByUser(userID)
+}
+
+func (s *ConversationService) CreateGroupConversation(userID string, createGroupPayload model.CreateGroupConversationPayload) (*model.Conversation, error) {
+	participantIDs := []string{userID}
+	for _, id := range createGroupPayload.UserIDs {
+		if !slices.Contains(participantIDs, id) {
+			participantIDs = append(participantIDs, id)
+		}
+	}
+
+	if len(participantIDs) > model.MaxGroupParticipants {
+		return nil, fmt.Errorf("%w: a group can have at most %d participants", model.ErrInvalid, model.MaxGroupParticipants)
+	}
+
+	if err := s.checkUsersExist(participantIDs[1:]); err != nil {
+		return nil, err
+	}
+
+	return s.conversationRepo.CreateGroupConversation(model.Conversation{
+		Name:           createGroupPayload.Name,
+		OwnerID:        userID,
+		ParticipantIDs: participantIDs,
+	})
+}
+
+func (s *ConversationService) RenameGroupConversation(userID, conversationID string, renamePayload model.RenameConversationPayload) (*model.Conversation, *model.Message, error) {
+	conversation, err := s.getGroupConversation(userID, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conversation.Name = renamePayload.Name
+	conversation, err = s.conversationRepo.UpdateConversation(*conversation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	message, err := s.createSystemMessage(userID, conversation.ID, model.SystemMessageContent{
+		Kind: model.SystemConversationRenamed,
+		Name: conversation.Name,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conversation, message, nil
+}
+
+// AddParticipants adds users to a group DM and returns those who were not
+// in it yet.
+func (s *ConversationService) AddParticipants(userID, conversationID string, addParticipantsPayload model.AddParticipantsPayload) (*model.Conversation, []string, *model.Message, error) {
+	conversation, err := s.getGroupConversation(userID, conversationID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	added := []string{}
+	for _, id := range addParticipantsPayload.UserIDs {
+		if !conversation.HasParticipant(id) && !slices.Contains(added, id) {
+			added = append(added, id)
+		}
+	}
+	if len(added) == 0 {
+		return conversation, added, nil, nil
+	}
+
+	if len(conversation.ParticipantIDs)+len(added) > model.MaxGroupParticipants {
+		return nil, nil, nil, fmt.Errorf("%w: a group can have at most %d participants", model.ErrInvalid, model.MaxGroupParticipants)
+	}
+
+	if err := s.checkUsersExist(added); err != nil {
+		return nil, nil, nil, err
+	}
+
+	added, err = s.conversationRepo.AddParticipants(conversation.ID, added)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(added) == 0 {
+		return conversation, added, nil, nil
+	}
+	conversation.ParticipantIDs = append(conversation.ParticipantIDs, added...)
+
+	message, err := s.createSystemMessage(userID, conversation.ID, model.SystemMessageContent{
+		Kind:    model.SystemParticipantsAdded,
+		UserIDs: added,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return conversation, added, message, nil
+}
+
+// RemoveParticipant kicks targetUserID from a group DM, which only the owner
+// may do, or makes the user leave when targetUserID is themselves. Ownership
+// passes to the longest standing participant when the owner leaves and the
+// conversation is deleted once nobody is left.
+func (s *ConversationService) RemoveParticipant(userID, conversationID, targetUserID string) (*model.Conversation, *model.Message, error) {
+	conversation, err := s.getGroupConversation(userID, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaving := targetUserID == userID
+	if !leaving && conversation.OwnerID != userID {
+		return nil, nil, fmt.Errorf("%w: only the owner can remove participants", model.ErrForbidden)
+	}
+	if !conversation.HasParticipant(targetUserID) {
+		return nil, nil, fmt.Errorf("participant %w", model.ErrNotFound)
+	}
+
+	if err := s.conversationRepo.RemoveParticipant(conversation.ID, targetUserID); err != nil {
+		return nil, nil, err
+	}
+	conversation.ParticipantIDs = slices.DeleteFunc(conversation.ParticipantIDs, func(id string) bool {
+		return id == targetUserID
+	})
+
+	if len(conversation.ParticipantIDs) == 0 {
+		if err := s.conversationRepo.DeleteConversation(conversation.ID); err != nil {
+			return nil, nil, err
+		}
+		return conversation, nil, nil
+	}
+
+	if conversation.OwnerID == targetUserID {
+		conversation.OwnerID = conversation.ParticipantIDs[0]
+		conversation, err = s.conversationRepo.UpdateConversation(*conversation)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	content := model.SystemMessageContent{
+		Kind:    model.SystemParticipantRemoved,
+		UserIDs: []string{targetUserID},
+	}
+	if leaving {
+		content.Kind = model.SystemParticipantLeft
+	}
+
+	message, err := s.createSystemMessage(userID, conversation.ID, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conversation, message, nil
+}
+
+func (s *ConversationService) getGroupConversation(userID, conversationID string) (*model.Conversation, error) {
+	conversation, err := s.GetConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.Type != model.GROUP {
+		return nil, fmt.Errorf("%w: not a group conversation", model.ErrInvalid)
+	}
+
+	return conversation, nil
+}
+
+func (s *ConversationService) checkUsersExist(userIDs []string) error {
+	for _, id := range userIDs {
+		if _, err := s.userRepo.FindUserByField("id", id); err != nil {
+			return fmt.Errorf("user %s %w", id, model.ErrNotFound)
+		}
+	}
+
+	return nil
+}
+
+func (s *ConversationService) createSystemMessage(userID, conversationID string, content model.SystemMessageContent) (*model.Message, error) {
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.messageRepo.CreateMessage(model.Message{
+		Type:           model.SYSTEM,
+		Content:        string(raw),
+		UserID:         userID,
+		ConversationID: conversationID,
+	})
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type InviteService struct {
+	inviteRepo  model.InviteRepository
+	serverRepo  model.ServerRepository
+	memberRepo  model.MemberRepository
+	permissions *PermissionResolver
+}
+
+func NewInviteService(inviteRepo model.InviteRepository, serverRepo model.ServerRepository, memberRepo model.MemberRepository, permissions *PermissionResolver) *InviteService {
+	return &InviteService{inviteRepo: inviteRepo, serverRepo: serverRepo, memberRepo: memberRepo, permissions: permissions}
+}
+
+// CreateInvite creates an invite to a server that members allowed to create
+// invites may share.
+func (s *InviteService) CreateInvite(userID, serverID string, createInvitePayload model.CreateInvitePayload) (*model.Invite, error) {
+	if _, err := s.permissions.Require(userID, serverID, model.PermCreateInvites, "create invites"); err != nil {
+		return nil, err
+	}
+
+	invite := model.Invite{
+		ServerID:  serverID,
+		CreatorID: userID,
+		MaxUses:   createInvitePayload.MaxUses,
+	}
+	if createInvitePayload.MaxAge > 0 {
+		expiresAt := time.Now().Add(time.Duration(createInvitePayload.MaxAge) * time.Second)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	return s.inviteRepo.CreateInvite(invite)
+}
+
+func (s *InviteService) GetServerInvites(userID, serverID string) ([]model.Invite, error) {
+	if _, err := s.permissions.Require(userID, serverID, model.PermManageInvites, "manage invites"); err != nil {
+		return nil, err
+	}
+
+	return s.inviteRepo.FindInvitesByServer(serverID)
+}
+
+// PreviewInvite describes the server behind a usable invite without joining
+// it.
+func (s *InviteService) PreviewInvite(code string) (*model.InvitePreview, error) {
+	invite, err := s.findUsableInvite(code)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := s.serverRepo.FindServerByID(invite.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberCount, err := s.memberRepo.CountMembersByServer(server.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.InvitePreview{
+		Code:        invite.Code,
+		ServerID:    server.ID,
+		ServerName:  server.Name,
+		IconURL:     server.IconURL,
+		MemberCount: memberCount,
+		ExpiresAt:   invite.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvite joins the server behind the invite with only its default
+// role, unless the user is banned from it or already a member.
+func (s *InviteService) AcceptInvite(userID, code string) (*model.Member, error) {
+	invite, err := s.findUsableInvite(code)
+	if err != nil {
+		return nil, err
+	}
+
+	banned, err := s.memberRepo.IsBanned(userID, invite.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	if banned {
+		return nil, fmt.Errorf("%w: you are banned from this server", model.ErrForbidden)
+	}
+
+	_, err = s.memberRepo.FindMemberByServer(userID, invite.ServerID)
+	if err == nil {
+		return nil, fmt.Errorf("%w: you are already a member of this server", model.ErrConflict)
+	}
+	if !errors.Is(err, model.ErrNotFound) {
+		return nil, err
+	}
+
+	return s.inviteRepo.AcceptInvite(*invite, userID)
+}
+
+func (s *InviteService) RevokeInvite(userID, serverID, code string) (*model.Invite, error) {
+	if _, err := s.permissions.Require(userID, serverID, model.PermManageInvites, "manage invites"); err != nil {
+		return nil, err
+	}
+
+	invite, err := s.inviteRepo.FindInviteByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if invite.ServerID != serverID {
+		return nil, fmt.Errorf("invite %w", model.ErrNotFound)
+	}
+	if invite.Default {
+		return nil, fmt.Errorf("%w: the default invite cannot be revoked, regenerate it instead", model.ErrInvalid)
+	}
+
+	return s.inviteRepo.DeleteInvite(*invite)
+}
+
+// RegenerateDefaultInvite replaces the default invite of a server, so the
+// old code stops working.
+func (s *InviteService) RegenerateDefaultInvite(userID, serverID string) (*model.Invite, error) {
+	if _, err := s.permissions.Require(userID, serverID, model.PermManageInvites, "manage invites"); err != nil {
+		return nil, err
+	}
+
+	return s.inviteRepo.RegenerateDefaultInvite(serverID)
+}
+
+func (s *InviteService) findUsableInvite(code string) (*model.Invite, error) {
+	invite, err := s.inviteRepo.FindInviteByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !invite.Usable(time.Now()) {
+		return nil, fmt.Errorf("%w: this invite is no longer valid", model.ErrInvalid)
+	}
+
+	return invite, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type MemberService struct {
+	memberRepo  model.MemberRepository
+	serverRepo  model.ServerRepository
+	permissions *PermissionResolver
+}
+
+func NewMemberService(memberRepo model.MemberRepository, serverRepo model.ServerRepository, permissions *PermissionResolver) *MemberService {
+	return &MemberService{memberRepo: memberRepo, serverRepo: serverRepo, permissions: permissions}
+}
+
+// GetServerMembers lists the members of a server the user is a member of.
+func (s *MemberService) GetServerMembers(userID, serverID string) ([]model.Member, error) {
+	if _, err := s.memberRepo.FindMemberByServer(userID, serverID); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, fmt.Errorf("%w: you are not a member of this server", model.ErrForbidden)
+		}
+		return nil, err
+	}
+
+	return s.memberRepo.FindMembersByServer(serverID)
+}
+
+// GetChannelViewers maps the id of each channel of a server the user is a
+// member of to the members who may view it.
+func (s *MemberService) GetChannelViewers(userID, serverID string, channels []model.Channel) (map[string][]model.Member, error) {
+	if _, err := s.permissions.Resolve(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	return s.permissions.ChannelViewers(serverID, channels)
+}
+
+// GetHiddenChannels maps the id of every channel of a server the user is a
+// member of to the members who may not view it.
+func (s *MemberService) GetHiddenChannels(userID, serverID string) (map[string][]model.Member, error) {
+	if _, err := s.permissions.Resolve(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	return s.permissions.HiddenChannels(userID, serverID)
+}
+
+func (s *MemberService) LeaveServer(userID, serverID string) (*model.Member, error) {
+	server, err := s.serverRepo.FindServerByID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if server.UserID == userID {
+		return nil, fmt.Errorf("%w: the owner cannot leave the server, delete it instead", model.ErrInvalid)
+	}
+
+	member, err := s.memberRepo.FindMemberByServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.DeleteMember(*member)
+}
+
+func (s *MemberService) KickMember(userID, serverID, memberID string) (*model.Member, error) {
+	target, err := s.checkCanRemove(userID, serverID, memberID, model.PermKickMembers, "kick members")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.DeleteMember(*target)
+}
+
+func (s *MemberService) BanMember(userID, serverID, memberID string) (*model.Member, error) {
+	target, err := s.checkCanRemove(userID, serverID, memberID, model.PermBanMembers, "ban members")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.BanMember(*target, userID)
+}
+
+// checkCanRemove verifies that userID may kick or ban memberID: the actor
+// needs perm and a higher role than the target, and the owner is never
+// removable.
+func (s *MemberService) checkCanRemove(userID, serverID, memberID string, perm model.Permission, action string) (*model.Member, error) {
+	actor, err := s.permissions.Require(userID, serverID, perm, action)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.memberRepo.FindMemberByID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ServerID != serverID {
+		return nil, fmt.Errorf("member %w", model.ErrNotFound)
+	}
+
+	if target.UserID == userID {
+		return nil, fmt.Errorf("%w: you cannot remove yourself, leave the server instead", model.ErrInvalid)
+	}
+
+	targetPermissions, err := s.permissions.resolveMember(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetPermissions.Owner {
+		return nil, fmt.Errorf("%w: you cannot remove this member", model.ErrForbidden)
+	}
+
+	if !actor.Outranks(targetPermissions.TopPosition) {
+		return nil, fmt.Errorf("%w: you cannot remove a member with an equal or higher role", model.ErrForbidden)
+	}
+
+	return target, nil
+}
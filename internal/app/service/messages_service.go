@@ -0,0 +1,264 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type MessageService struct {
+	messageRepo      model.MessageRepository
+	conversationRepo model.ConversationRepository
+	readStateRepo    model.ReadStateRepository
+	permissions      *PermissionResolver
+}
+
+func NewMessageService(messageRepo model.MessageRepository, conversationRepo model.ConversationRepository, readStateRepo model.ReadStateRepository, permissions *PermissionResolver) *MessageService {
+	return &MessageService{messageRepo: messageRepo, conversationRepo: conversationRepo, readStateRepo: readStateRepo, permissions: permissions}
+}
+
+func (s *MessageService) CreateMessage(userID string, createMessagePayload model.CreateMessagePayload) (*model.Message, error) {
+	if (createMessagePayload.ChannelID == "") == (createMessagePayload.ConversationID == "") {
+		return nil, fmt.Errorf("%w: exactly one of channel_id or conversation_id is required", model.ErrInvalid)
+	}
+
+	message := model.Message{
+		Content:        createMessagePayload.Content,
+		UserID:         userID,
+		ChannelID:      createMessagePayload.ChannelID,
+		ConversationID: createMessagePayload.ConversationID,
+	}
+
+	if message.ChannelID != "" {
+		permissions, err := s.checkChannelContent(userID, message.ChannelID, message.Content)
+		if err != nil {
+			return nil, err
+		}
+		message.MemberID = permissions.Member.ID
+	} else if err := s.checkParticipant(userID, message.ConversationID); err != nil {
+		return nil, err
+	}
+
+	return s.messageRepo.CreateMessage(message)
+}
+
+func (s *MessageService) GetMessageByID(id string) (*model.Message, error) {
+	return s.messageRepo.FindMessageByID(id)
+}
+
+func (s *MessageService) GetChannelMessages(userID, channelID string, pageParams utils.PageParams) (*utils.Page[model.Message], error) {
+	if _, _, err := s.permissions.RequireChannel(userID, channelID, model.PermViewChannels, "view this channel"); err != nil {
+		return nil, err
+	}
+
+	return s.getMessages("channel_id", channelID, pageParams)
+}
+
+func (s *MessageService) GetConversationMessages(userID, conversationID string, pageParams utils.PageParams) (*utils.Page[model.Message], error) {
+	if err := s.checkParticipant(userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	return s.getMessages("conversation_id", conversationID, pageParams)
+}
+
+func (s *MessageService) getMessages(field, targetID string, pageParams utils.PageParams) (*utils.Page[model.Message], error) {
+	var cursorID string
+	for _, c := range []string{pageParams.Before, pageParams.After, pageParams.Around} {
+		if c == "" {
+			continue
+		}
+		if cursorID != "" {
+			return nil, fmt.Errorf("%w: only one of before, after or around may be set", model.ErrInvalid)
+		}
+		cursorID = c
+	}
+
+	var cursor *model.Message
+	if cursorID != "" {
+		c, err := s.messageRepo.FindMessageByID(cursorID)
+		if err != nil {
+			return nil, err
+		}
+		if (field == "channel_id" && c.ChannelID != targetID) || (field == "conversation_id" && c.ConversationID != targetID) {
+			return nil, fmt.Errorf("cursor message %w", model.ErrNotFound)
+		}
+		cursor = c
+	}
+
+	limit := pageParams.Limit
+	page := &utils.Page[model.Message]{Limit: limit}
+
+	switch {
+	case pageParams.After != "":
+		messages, err := s.messageRepo.FindMessagesAfter(field, targetID, cursor, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		page.HasBefore = true
+		page.HasAfter = len(messages) > limit
+		page.Data = messages[:min(len(messages), limit)]
+
+	case pageParams.Around != "":
+		half := limit / 2
+		before, err := s.messageRepo.FindMessagesBefore(field, targetID, cursor, half+1)
+		if err != nil {
+			return nil, err
+		}
+		after, err := s.messageRepo.FindMessagesAfter(field, targetID, cursor, limit-half)
+		if err != nil {
+			return nil, err
+		}
+
+		page.HasBefore = len(before) > half
+		if page.HasBefore {
+			before = before[1:]
+		}
+		afterLimit := limit - half - 1
+		page.HasAfter = len(after) > afterLimit
+		after = after[:min(len(after), afterLimit)]
+
+		page.Data = before
+		if !cursor.Deleted {
+			page.Data = append(page.Data, *cursor)
+		}
+		page.Data = append(page.Data, after...)
+
+	default:
+		messages, err := s.messageRepo.FindMessagesBefore(field, targetID, cursor, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		page.HasBefore = len(messages) > limit
+		if page.HasBefore {
+			messages = messages[1:]
+		}
+		page.HasAfter = cursor != nil
+		page.Data = messages
+	}
+
+	if len(page.Data) > 0 {
+		page.Before = page.Data[0].ID
+		page.After = page.Data[len(page.Data)-1].ID
+	}
+
+	return page, nil
+}
+
+func (s *MessageService) UpdateMessage(userID, messageID string, updateMessagePayload model.UpdateMessagePayload) (*model.Message, error) {
+	message, err := s.messageRepo.FindMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.Deleted {
+		return nil, fmt.Errorf("message %w", model.ErrNotFound)
+	}
+
+	if message.UserID != userID || message.Type == model.SYSTEM {
+		return nil, fmt.Errorf("%w: only the author can edit this message", model.ErrForbidden)
+	}
+
+	if message.ChannelID != "" {
+		if _, err := s.checkChannelContent(userID, message.ChannelID, updateMessagePayload.Content); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkParticipant(userID, message.ConversationID); err != nil {
+		return nil, err
+	}
+
+	message.Content = updateMessagePayload.Content
+	return s.messageRepo.UpdateMessageContent(*message)
+}
+
+func (s *MessageService) DeleteMessage(userID, messageID string) (*model.Message, error) {
+	message, err := s.messageRepo.FindMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.Deleted {
+		return nil, fmt.Errorf("message %w", model.ErrNotFound)
+	}
+
+	if message.ConversationID != "" {
+		if err := s.checkParticipant(userID, message.ConversationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if message.UserID != userID {
+		canModerate, err := s.canModerate(userID, message)
+		if err != nil {
+			return nil, err
+		}
+		if !canModerate {
+			return nil, fmt.Errorf("%w: only the author or a moderator can delete this message", model.ErrForbidden)
+		}
+	}
+
+	return s.messageRepo.SoftDeleteMessage(*message)
+}
+
+// checkChannelContent verifies that userID may post content in a channel,
+// which has to be visible to them. Mentioning everyone needs its own
+// permission.
+func (s *MessageService) checkChannelContent(userID, channelID, content string) (*model.MemberPermissions, error) {
+	perm := model.PermViewChannels
+	if model.MentionsEveryone(content) {
+		perm |= model.PermMentionEveryone
+	}
+
+	_, permissions, err := s.permissions.RequireChannel(userID, channelID, perm, "mention everyone")
+	return permissions, err
+}
+
+// canModerate reports whether userID may manage messages in the message's
+// channel. Conversation messages have no moderators.
+func (s *MessageService) canModerate(userID string, message *model.Message) (bool, error) {
+	if message.ChannelID == "" {
+		return false, nil
+	}
+
+	_, _, err := s.permissions.RequireChannel(userID, message.ChannelID, model.PermManageMessages, "manage messages")
+	if errors.Is(err, model.ErrForbidden) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// AckMessage marks everything up to messageID as read for userID in the
+// message's channel or conversation.
+func (s *MessageService) AckMessage(userID, messageID string) (*model.ReadState, error) {
+	message, err := s.messageRepo.FindMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.Deleted {
+		return nil, fmt.Errorf("message %w", model.ErrNotFound)
+	}
+
+	if message.ChannelID != "" {
+		if _, _, err := s.permissions.RequireChannel(userID, message.ChannelID, model.PermViewChannels, "view this channel"); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkParticipant(userID, message.ConversationID); err != nil {
+		return nil, err
+	}
+
+	return s.readStateRepo.UpsertReadState(userID, *message)
+}
+
+func (s *MessageService) checkParticipant(userID, conversationID string) error {
+	conversation, err := s.conversationRepo.FindConversationByID(conversationID)
+	if err != nil {
+		return err
+	}
+
+	if !conversation.HasParticipant(userID) {
+		return fmt.Errorf("%w: you are not a participant of this conversation", model.ErrForbidden)
+	}
+
+	return nil
+}
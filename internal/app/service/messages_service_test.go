@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+// fakeMessageRepo keeps the messages of one channel, oldest first.
+type fakeMessageRepo struct {
+	model.MessageRepository
+	messages []model.Message
+}
+
+func (r *fakeMessageRepo) FindMessageByID(id string) (*model.Message, error) {
+	for _, message := range r.messages {
+		if message.ID == id {
+			return &message, nil
+		}
+	}
+	return nil, fmt.Errorf("message %w", model.ErrNotFound)
+}
+
+func (r *fakeMessageRepo) index(cursor *model.Message) int {
+	if cursor == nil {
+		return len(r.messages)
+	}
+	return slices.IndexFunc(r.messages, func(m model.Message) bool { return m.ID == cursor.ID })
+}
+
+func (r *fakeMessageRepo) FindMessagesBefore(field, targetID string, cursor *model.Message, limit int) ([]model.Message, error) {
+	end := r.index(cursor)
+	return slices.Clone(r.messages[max(0, end-limit):end]), nil
+}
+
+func (r *fakeMessageRepo) FindMessagesAfter(field, targetID string, cursor *model.Message, limit int) ([]model.Message, error) {
+	start := r.index(cursor) + 1
+	return slices.Clone(r.messages[start:min(len(r.messages), start+limit)]), nil
+}
+
+func TestGetMessagesCursors(t *testing.T) {
+	repo := &fakeMessageRepo{}
+	for i := 1; i <= 5; i++ {
+		repo.messages = append(repo.messages, model.Message{ID: fmt.Sprintf("m%d", i), ChannelID: "c1"})
+	}
+	s := &MessageService{messageRepo: repo}
+
+	tests := []struct {
+		name      string
+		params    utils.PageParams
+		wantIDs   []string
+		hasBefore bool
+		hasAfter  bool
+	}{
+		{name: "latest", params: utils.PageParams{Limit: 2}, wantIDs: []string{"m4", "m5"}, hasBefore: true},
+		{name: "everything", params: utils.PageParams{Limit: 10}, wantIDs: []string{"m1", "m2", "m3", "m4", "m5"}},
+		{name: "before", params: utils.PageParams{Limit: 2, Before: "m4"}, wantIDs: []string{"m2", "m3"}, hasBefore: true, hasAfter: true},
+		{name: "before the start", params: utils.PageParams{Limit: 2, Before: "m2"}, wantIDs: []string{"m1"}, hasAfter: true},
+		{name: "after", params: utils.PageParams{Limit: 2, After: "m1"}, wantIDs: []string{"m2", "m3"}, hasBefore: true, hasAfter: true},
+		{name: "after to the end", params: utils.PageParams{Limit: 2, After: "m3"}, wantIDs: []string{"m4", "m5"}, hasBefore: true},
+		{name: "around", params: utils.PageParams{Limit: 3, Around: "m3"}, wantIDs: []string{"m2", "m3", "m4"}, hasBefore: true, hasAfter: true},
+		{name: "around the start", params: utils.PageParams{Limit: 3, Around: "m1"}, wantIDs: []string{"m1", "m2"}, hasAfter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.getMessages("channel_id", "c1", tt.params)
+			if err != nil {
+				t.Fatalf("getMessages failed: %v", err)
+			}
+
+			var ids []string
+			for _, message := range page.Data {
+				ids = append(ids, message.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("got messages %v, want %v", ids, tt.wantIDs)
+			}
+			if page.HasBefore != tt.hasBefore || page.HasAfter != tt.hasAfter {
+				t.Errorf("got has_before %v and has_after %v, want %v and %v", page.HasBefore, page.HasAfter, tt.hasBefore, tt.hasAfter)
+			}
+			if page.Before != tt.wantIDs[0] || page.After != tt.wantIDs[len(tt.wantIDs)-1] {
+				t.Errorf("got cursors %q and %q, want the first and last message", page.Before, page.After)
+			}
+		})
+	}
+}
+
+func TestGetMessagesCursorFromAnotherChannel(t *testing.T) {
+	repo := &fakeMessageRepo{messages: []model.Message{{ID: "m1", ChannelID: "c2"}}}
+	s := &MessageService{messageRepo: repo}
+
+	_, err := s.getMessages("channel_id", "c1", utils.PageParams{Limit: 2, Before: "m1"})
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetMessagesRejectsSeveralCursors(t *testing.T) {
+	repo := &fakeMessageRepo{messages: []model.Message{{ID: "m1", ChannelID: "c1"}, {ID: "m2", ChannelID: "c1"}}}
+	s := &MessageService{messageRepo: repo}
+
+	_, err := s.getMessages("channel_id", "c1", utils.PageParams{Limit: 2, Before: "m2", After: "m1"})
+	if !errors.Is(err, model.ErrInvalid) {
+		t.Errorf("got %v, want ErrInvalid", err)
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// PermissionResolver works out what a member may do in a server from the
+// roles it holds, and in a channel from the overwrites of that channel.
+type PermissionResolver struct {
+	serverRepo  model.ServerRepository
+	memberRepo  model.MemberRepository
+	roleRepo    model.RoleRepository
+	channelRepo model.ChannelRepository
+}
+
+func NewPermissionResolver(serverRepo model.ServerRepository, memberRepo model.MemberRepository, roleRepo model.RoleRepository, channelRepo model.ChannelRepository) *PermissionResolver {
+	return &PermissionResolver{serverRepo: serverRepo, memberRepo: memberRepo, roleRepo: roleRepo, channelRepo: channelRepo}
+}
+
+// Resolve combines the permissions of every role userID holds in the server,
+// failing with ErrForbidden when the user is not a member.
+func (r *PermissionResolver) Resolve(userID, serverID string) (*model.MemberPermissions, error) {
+	member, err := r.memberRepo.FindMemberByServer(userID, serverID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, fmt.Errorf("%w: you are not a member of this server", model.ErrForbidden)
+		}
+		return nil, err
+	}
+
+	return r.resolveMember(member)
+}
+
+func (r *PermissionResolver) resolveMember(member *model.Member) (*model.MemberPermissions, error) {
+	server, err := r.serverRepo.FindServerByID(member.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := r.roleRepo.FindRolesByMember(member.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return combineRoles(server, member, roles), nil
+}
+
+// combineRoles resolves the permissions of member from the roles of its
+// server, of which only the default role and those it holds count.
+func combineRoles(server *model.ServerModel, member *model.Member, roles []model.Role) *model.MemberPermissions {
+	permissions := &model.MemberPermissions{
+		Member: member,
+		Owner:  server.UserID == member.UserID,
+	}
+	for _, role := range roles {
+		if role.Default {
+			permissions.DefaultRoleID = role.ID
+		} else if !slices.Contains(member.RoleIDs, role.ID) {
+			continue
+		}
+		permissions.Permissions |= role.Permissions
+		permissions.TopPosition = max(permissions.TopPosition, role.Position)
+	}
+
+	return permissions
+}
+
+// Require resolves the permissions of userID in the server and fails with
+// ErrForbidden unless they include perm. action completes "you do not have
+// permission to" in the error.
+func (r *PermissionResolver) Require(userID, serverID string, perm model.Permission, action string) (*model.MemberPermissions, error) {
+	permissions, err := r.Resolve(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !permissions.Has(perm) {
+		return nil, fmt.Errorf("%w: you do not have permission to %s", model.ErrForbidden, action)
+	}
+
+	return permissions, nil
+}
+
+// ResolveChannel resolves the permissions of userID in the server of the
+// channel, with the overwrites of the channel applied.
+func (r *PermissionResolver) ResolveChannel(userID string, channel *model.Channel) (*model.MemberPermissions, error) {
+	permissions, err := r.Resolve(userID, channel.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	overwrites, err := r.channelRepo.FindChannelOverwrites(channel.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions.ForChannel(overwrites), nil
+}
+
+// RequireChannel is Require for a channel. Users who cannot view the channel
+// are told so whatever perm is.
+func (r *PermissionResolver) RequireChannel(userID, channelID string, perm model.Permission, action string) (*model.Channel, *model.MemberPermissions, error) {
+	channel, err := r.channelRepo.FindChannelByID(channelID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	permissions, err := r.ResolveChannel(userID, channel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !permissions.Has(model.PermViewChannels) {
+		return nil, nil, fmt.Errorf("%w: you cannot view this channel", model.ErrForbidden)
+	}
+	if !permissions.Has(perm) {
+		return nil, nil, fmt.Errorf("%w: you do not have permission to %s", model.ErrForbidden, action)
+	}
+
+	return channel, permissions, nil
+}
+
+// VisibleChannels keeps the channels of a server the member may view.
+func (r *PermissionResolver) VisibleChannels(permissions *model.MemberPermissions, channels []model.Channel) ([]model.Channel, error) {
+	overwrites, err := r.channelRepo.FindChannelOverwritesByServer(permissions.Member.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	byChannel := make(map[string][]model.ChannelOverwrite)
+	for _, overwrite := range overwrites {
+		byChannel[overwrite.ChannelID] = append(byChannel[overwrite.ChannelID], overwrite)
+	}
+
+	visible := []model.Channel{}
+	for _, channel := range channels {
+		if permissions.ForChannel(byChannel[channel.ID]).Has(model.PermViewChannels) {
+			visible = append(visible, channel)
+		}
+	}
+
+	return visible, nil
+}
+
+// MembersLacking lists the members of the server of a channel who do not
+// have perm in it.
+func (r *PermissionResolver) MembersLacking(channel *model.Channel, perm model.Permission) ([]model.Member, error) {
+	access, err := r.loadAccess(channel.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, lacking := access.split(channel.ID, perm)
+	return lacking, nil
+}
+
+// ChannelViewers maps the id of each channel, all of which belong to the
+// server, to the members who may view it.
+func (r *PermissionResolver) ChannelViewers(serverID string, channels []model.Channel) (map[string][]model.Member, error) {
+	access, err := r.loadAccess(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewers := make(map[string][]model.Member, len(channels))
+	for _, channel := range channels {
+		viewers[channel.ID], _ = access.split(channel.ID, model.PermViewChannels)
+	}
+
+	return viewers, nil
+}
+
+// HiddenChannels maps the id of every channel of the server to the members
+// who may not view it. userID only picks whose unread counts are loaded
+// along with the channels.
+func (r *PermissionResolver) HiddenChannels(userID, serverID string) (map[string][]model.Member, error) {
+	channels, err := r.channelRepo.FindChannelsByServer(serverID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := r.loadAccess(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden := make(map[string][]model.Member, len(channels))
+	for _, channel := range channels {
+		_, hidden[channel.ID] = access.split(channel.ID, model.PermViewChannels)
+	}
+
+	return hidden, nil
+}
+
+// serverAccess is what resolving the permissions of every member of a
+// server in its channels takes, loaded once for all of them.
+type serverAccess struct {
+	server     *model.ServerModel
+	members    []model.Member
+	roles      []model.Role
+	overwrites map[string][]model.ChannelOverwrite
+}
+
+func (r *PermissionResolver) loadAccess(serverID string) (*serverAccess, error) {
+	server, err := r.serverRepo.FindServerByID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := r.memberRepo.FindMembersByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := r.roleRepo.FindRolesByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	overwrites, err := r.channelRepo.FindChannelOverwritesByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &serverAccess{
+		server:     server,
+		members:    members,
+		roles:      roles,
+		overwrites: make(map[string][]model.ChannelOverwrite),
+	}
+	for _, overwrite := range overwrites {
+		access.overwrites[overwrite.ChannelID] = append(access.overwrites[overwrite.ChannelID], overwrite)
+	}
+
+	return access, nil
+}
+
+// split divides the members into those who have perm in the channel and
+// those who do not.
+func (a *serverAccess) split(channelID string, perm model.Permission) ([]model.Member, []model.Member) {
+	with, without := []model.Member{}, []model.Member{}
+	for i := range a.members {
+		if combineRoles(a.server, &a.members[i], a.roles).ForChannel(a.overwrites[channelID]).Has(perm) {
+			with = append(with, a.members[i])
+		} else {
+			without = append(without, a.members[i])
+		}
+	}
+
+	return with, without
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type fakeServerRepo struct {
+	model.ServerRepository
+	servers map[string]model.ServerModel
+}
+
+func (r *fakeServerRepo) FindServerByID(id string) (*model.ServerModel, error) {
+	server, ok := r.servers[id]
+	if !ok {
+		return nil, fmt.Errorf("server %w", model.ErrNotFound)
+	}
+	return &server, nil
+}
+
+type fakeMemberRepo struct {
+	model.MemberRepository
+	members []model.Member
+}
+
+func (r *fakeMemberRepo) FindMemberByServer(userID, serverID string) (*model.Member, error) {
+	for _, member := range r.members {
+		if member.UserID == userID && member.ServerID == serverID {
+			return &member, nil
+		}
+	}
+	return nil, fmt.Errorf("member %w", model.ErrNotFound)
+}
+
+func (r *fakeMemberRepo) FindMembersByServer(serverID string) ([]model.Member, error) {
+	members := []model.Member{}
+	for _, member := range r.members {
+		if member.ServerID == serverID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// fakeRoleRepo returns every role of the server, like FindRolesByServer,
+// and relies on the resolver to keep those the member holds.
+type fakeRoleRepo struct {
+	model.RoleRepository
+	roles []model.Role
+}
+
+func (r *fakeRoleRepo) FindRolesByServer(serverID string) ([]model.Role, error) {
+	return r.roles, nil
+}
+
+func (r *fakeRoleRepo) FindRolesByMember(memberID string) ([]model.Role, error) {
+	return r.roles, nil
+}
+
+type fakeChannelRepo struct {
+	model.ChannelRepository
+	channels   map[string]model.Channel
+	overwrites []model.ChannelOverwrite
+}
+
+func (r *fakeChannelRepo) FindChannelByID(id string) (*model.Channel, error) {
+	channel, ok := r.channels[id]
+	if !ok {
+		return nil, fmt.Errorf("channel %w", model.ErrNotFound)
+	}
+	return &channel, nil
+}
+
+func (r *fakeChannelRepo) FindChannelsByServer(serverID, userID string) ([]model.Channel, error) {
+	channels := []model.Channel{}
+	for _, channel := range r.channels {
+		if channel.ServerID == serverID {
+			channels = append(channels, channel)
+		}
+	}
+	return channels, nil
+}
+
+func (r *fakeChannelRepo) FindChannelOverwrites(channelID string) ([]model.ChannelOverwrite, error) {
+	overwrites := []model.ChannelOverwrite{}
+	for _, overwrite := range r.overwrites {
+		if overwrite.ChannelID == channelID {
+			overwrites = append(overwrites, overwrite)
+		}
+	}
+	return overwrites, nil
+}
+
+func (r *fakeChannelRepo) FindChannelOverwritesByServer(serverID string) ([]model.ChannelOverwrite, error) {
+	return r.overwrites, nil
+}
+
+// newTestResolver builds a resolver over server s1, owned by the user
+// "owner", with the default role, a moderator role at position 1 and an
+// admin role at position 2.
+func newTestResolver(channels *fakeChannelRepo) *PermissionResolver {
+	servers := &fakeServerRepo{servers: map[string]model.ServerModel{
+		"s1": {ID: "s1", UserID: "owner"},
+	}}
+	members := &fakeMemberRepo{members: []model.Member{
+		{ID: "m-owner", UserID: "owner", ServerID: "s1"},
+		{ID: "m-member", UserID: "member", ServerID: "s1"},
+		{ID: "m-mod", UserID: "mod", ServerID: "s1", RoleIDs: []string{"mod"}},
+		{ID: "m-admin", UserID: "admin", ServerID: "s1", RoleIDs: []string{"mod", "admin"}},
+	}}
+	roles := &fakeRoleRepo{roles: []model.Role{
+		{ID: "everyone", Default: true, Permissions: model.DefaultPermissions},
+		{ID: "mod", Position: 1, Permissions: model.PermKickMembers | model.PermManageMessages},
+		{ID: "admin", Position: 2, Permissions: model.PermAdministrator},
+	}}
+	if channels == nil {
+		channels = &fakeChannelRepo{}
+	}
+
+	return NewPermissionResolver(servers, members, roles, channels)
+}
+
+func TestPermissionResolverResolve(t *testing.T) {
+	r := newTestResolver(nil)
+
+	tests := []struct {
+		userID      string
+		owner       bool
+		permissions model.Permission
+		topPosition int
+	}{
+		{userID: "owner", owner: true, permissions: model.DefaultPermissions},
+		{userID: "member", permissions: model.DefaultPermissions},
+		{userID: "mod", permissions: model.DefaultPermissions | model.PermKickMembers | model.PermManageMessages, topPosition: 1},
+		{userID: "admin", permissions: model.DefaultPermissions | model.PermKickMembers | model.PermManageMessages | model.PermAdministrator, topPosition: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			got, err := r.Resolve(tt.userID, "s1")
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if got.Owner != tt.owner || got.Permissions != tt.permissions || got.TopPosition != tt.topPosition {
+				t.Errorf("got owner %v, permissions %b and top position %d, want %v, %b and %d",
+					got.Owner, got.Permissions, got.TopPosition, tt.owner, tt.permissions, tt.topPosition)
+			}
+			if got.DefaultRoleID != "everyone" {
+				t.Errorf("got default role %q, want everyone", got.DefaultRoleID)
+			}
+		})
+	}
+}
+
+func TestPermissionResolverResolveNonMember(t *testing.T) {
+	r := newTestResolver(nil)
+
+	if _, err := r.Resolve("stranger", "s1"); !errors.Is(err, model.ErrForbidden) {
+		t.Errorf("got %v, want ErrForbidden", err)
+	}
+}
+
+func TestPermissionResolverRequire(t *testing.T) {
+	r := newTestResolver(nil)
+
+	tests := []struct {
+		userID string
+		perm   model.Permission
+		want   error
+	}{
+		{userID: "owner", perm: model.PermManageServer},
+		{userID: "member", perm: model.PermCreateInvites},
+		{userID: "member", perm: model.PermKickMembers, want: model.ErrForbidden},
+		{userID: "mod", perm: model.PermKickMembers},
+		{userID: "mod", perm: model.PermBanMembers, want: model.ErrForbidden},
+		{userID: "admin", perm: model.PermBanMembers},
+		{userID: "stranger", perm: model.PermCreateInvites, want: model.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %b", tt.userID, tt.perm), func(t *testing.T) {
+			_, err := r.Require(tt.userID, "s1", tt.perm, "test")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// newTestChannels has a public channel and a staff channel only the
+// moderator role may view.
+func newTestChannels() *fakeChannelRepo {
+	return &fakeChannelRepo{
+		channels: map[string]model.Channel{
+			"public": {ID: "public", ServerID: "s1"},
+			"staff":  {ID: "staff", ServerID: "s1"},
+		},
+		overwrites: []model.ChannelOverwrite{
+			{ChannelID: "staff", RoleID: "everyone", Deny: model.PermViewChannels},
+			{ChannelID: "staff", RoleID: "mod", Allow: model.PermViewChannels},
+			{ChannelID: "public", RoleID: "mod", Deny: model.PermManageMessages},
+		},
+	}
+}
+
+func TestPermissionResolverRequireChannel(t *testing.T) {
+	r := newTestResolver(newTestChannels())
+
+	tests := []struct {
+		userID    string
+		channelID string
+		perm      model.Permission
+		want      error
+	}{
+		{userID: "member", channelID: "public", perm: model.PermViewChannels},
+		{userID: "member", channelID: "staff", perm: model.PermViewChannels, want: model.ErrForbidden},
+		{userID: "mod", channelID: "staff", perm: model.PermViewChannels},
+		{userID: "mod", channelID: "staff", perm: model.PermManageMessages},
+		{userID: "mod", channelID: "public", perm: model.PermManageMessages, want: model.ErrForbidden},
+		{userID: "admin", channelID: "public", perm: model.PermManageMessages},
+		{userID: "owner", channelID: "staff", perm: model.PermManageChannels},
+		{userID: "stranger", channelID: "public", perm: model.PermViewChannels, want: model.ErrForbidden},
+		{userID: "member", channelID: "missing", perm: model.PermViewChannels, want: model.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s in %s", tt.userID, tt.channelID), func(t *testing.T) {
+			_, _, err := r.RequireChannel(tt.userID, tt.channelID, tt.perm, "test")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionResolverVisibleChannels(t *testing.T) {
+	r := newTestResolver(newTestChannels())
+	channels := []model.Channel{{ID: "public", ServerID: "s1"}, {ID: "staff", ServerID: "s1"}}
+
+	tests := []struct {
+		userID string
+		want   []string
+	}{
+		{userID: "member", want: []string{"public"}},
+		{userID: "mod", want: []string{"public", "staff"}},
+		{userID: "owner", want: []string{"public", "staff"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			permissions, err := r.Resolve(tt.userID, "s1")
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+
+			visible, err := r.VisibleChannels(permissions, channels)
+			if err != nil {
+				t.Fatalf("VisibleChannels failed: %v", err)
+			}
+
+			var ids []string
+			for _, channel := range visible {
+				ids = append(ids, channel.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionResolverMembersLacking(t *testing.T) {
+	r := newTestResolver(newTestChannels())
+
+	lacking, err := r.MembersLacking(&model.Channel{ID: "staff", ServerID: "s1"}, model.PermViewChannels)
+	if err != nil {
+		t.Fatalf("MembersLacking failed: %v", err)
+	}
+
+	var ids []string
+	for _, member := range lacking {
+		ids = append(ids, member.UserID)
+	}
+	if want := []string{"member"}; !slices.Equal(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
+
+func TestPermissionResolverChannelViewers(t *testing.T) {
+	r := newTestResolver(newTestChannels())
+	channels := []model.Channel{{ID: "public", ServerID: "s1"}, {ID: "staff", ServerID: "s1"}}
+
+	viewers, err := r.ChannelViewers("s1", channels)
+	if err != nil {
+		t.Fatalf("ChannelViewers failed: %v", err)
+	}
+
+	want := map[string][]string{
+		"public": {"owner", "member", "mod", "admin"},
+		"staff":  {"owner", "mod", "admin"},
+	}
+	for channelID, wantIDs := range want {
+		var ids []string
+		for _, member := range viewers[channelID] {
+			ids = append(ids, member.UserID)
+		}
+		if !slices.Equal(ids, wantIDs) {
+			t.Errorf("viewers of %s = %v, want %v", channelID, ids, wantIDs)
+		}
+	}
+}
+
+func TestPermissionResolverHiddenChannels(t *testing.T) {
+	r := newTestResolver(newTestChannels())
+
+	hidden, err := r.HiddenChannels("owner", "s1")
+	if err != nil {
+		t.Fatalf("HiddenChannels failed: %v", err)
+	}
+
+	want := map[string][]string{"public": nil, "staff": {"member"}}
+	if len(hidden) != len(want) {
+		t.Errorf("got %d channels, want %d", len(hidden), len(want))
+	}
+	for channelID, wantIDs := range want {
+		var ids []string
+		for _, member := range hidden[channelID] {
+			ids = append(ids, member.UserID)
+		}
+		if !slices.Equal(ids, wantIDs) {
+			t.Errorf("hidden from %s = %v, want %v", channelID, ids, wantIDs)
+		}
+	}
+}
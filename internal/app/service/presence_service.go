@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// PresenceService derives the status others see from the status of a
+// user's connections, which the websocket hub tracks across instances:
+// OFFLINE without connections, otherwise the manual status when one is set,
+// otherwise ONLINE or IDLE from the connections.
+type PresenceService struct {
+	userRepo model.UserRepository
+
+	// mu keeps concurrent refreshes of this instance from storing an older
+	// status over a newer one.
+	mu sync.Mutex
+}
+
+func NewPresenceService(userRepo model.UserRepository) *PresenceService {
+	return &PresenceService{userRepo: userRepo}
+}
+
+// RefreshStatus recomputes and stores the status of userID, whose
+// connections have the status connection, after either that or their
+// manual status changed.
+func (s *PresenceService) RefreshStatus(userID string, connection model.ProfileStatus) (model.ProfileStatus, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.userRepo.FindUserByFieldWithProfile("id", userID)
+	if err != nil {
+		return "", false, err
+	}
+	if user.Profile == nil {
+		return "", false, fmt.Errorf("profile %w", model.ErrNotFound)
+	}
+
+	status := connection
+	if status != model.OFFLINE && user.Profile.ManualStatus != "" {
+		status = user.Profile.ManualStatus
+	}
+
+	if status == user.Profile.Status {
+		return status, false, nil
+	}
+
+	if err := s.userRepo.UpdateUserStatus(userID, status); err != nil {
+		return "", false, err
+	}
+
+	return status, true, nil
+}
+
+// GetPresenceAudience returns the users who should see the presence of
+// userID: everyone sharing a server or a conversation with them.
+func (s *PresenceService) GetPresenceAudience(userID string) ([]string, error) {
+	return s.userRepo.FindRelatedUserIDs(userID)
+}
+
+func (s *PresenceService) ResetStatuses() error {
+	return s.userRepo.ResetUserStatuses()
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type RoleService struct {
+	roleRepo    model.RoleRepository
+	memberRepo  model.MemberRepository
+	permissions *PermissionResolver
+}
+
+func NewRoleService(roleRepo model.RoleRepository, memberRepo model.MemberRepository, permissions *PermissionResolver) *RoleService {
+	return &RoleService{roleRepo: roleRepo, memberRepo: memberRepo, permissions: permissions}
+}
+
+// GetServerRoles lists the roles of a server the user is a member of, from
+// the highest.
+func (s *RoleService) GetServerRoles(userID, serverID string) ([]model.Role, error) {
+	if _, err := s.permissions.Resolve(userID, serverID); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.FindRolesByServer(serverID)
+}
+
+// CreateRole adds a role right above the default role. Members allowed to
+// manage roles may only grant the permissions they have themselves.
+func (s *RoleService) CreateRole(userID, serverID string, createRolePayload model.CreateRolePayload) (*model.Role, error) {
+	actor, err := s.permissions.Require(userID, serverID, model.PermManageRoles, "manage roles")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkGrant(actor, createRolePayload.Permissions); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.CreateRole(model.Role{
+		ServerID:    serverID,
+		Name:        createRolePayload.Name,
+		Color:       createRolePayload.Color,
+		Permissions: createRolePayload.Permissions,
+	})
+}
+
+// UpdateRole changes a role below the highest role of the user.
+func (s *RoleService) UpdateRole(userID, serverID, roleID string, updateRolePayload model.UpdateRolePayload) (*model.Role, error) {
+	if updateRolePayload.Name == nil && updateRolePayload.Color == nil && updateRolePayload.Permissions == nil {
+		return nil, fmt.Errorf("%w: nothing to update", model.ErrInvalid)
+	}
+
+	role, actor, err := s.checkRole(userID, serverID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if updateRolePayload.Permissions != nil {
+		if err := checkGrant(actor, *updateRolePayload.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.roleRepo.UpdateRole(role.ID, updateRolePayload)
+}
+
+// DeleteRole deletes a role below the highest role of the user, taking it
+// away from the members holding it. The default role cannot be deleted.
+func (s *RoleService) DeleteRole(userID, serverID, roleID string) (*model.Role, error) {
+	role, _, err := s.checkRole(userID, serverID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Default {
+		return nil, fmt.Errorf("%w: the default role cannot be deleted", model.ErrInvalid)
+	}
+
+	return s.roleRepo.DeleteRole(*role)
+}
+
+// ReorderRoles moves the roles of a server into the order given by the
+// payload, from the highest. Only the roles below the highest role of the
+// user may move, and not above it.
+func (s *RoleService) ReorderRoles(userID, serverID string, reorderRolesPayload model.ReorderRolesPayload) ([]model.Role, error) {
+	actor, err := s.permissions.Require(userID, serverID, model.PermManageRoles, "manage roles")
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleRepo.FindRolesByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIDs := reorderRolesPayload.RoleIDs
+	positions := make(map[string]int, len(roleIDs))
+	for i, roleID := range roleIDs {
+		positions[roleID] = len(roleIDs) - i
+	}
+
+	for _, role := range roles {
+		position, ok := positions[role.ID]
+		if !ok || position == role.Position {
+			continue
+		}
+		if !actor.Outranks(role.Position) || !actor.Outranks(position) {
+			return nil, fmt.Errorf("%w: you can only move roles below your highest role", model.ErrForbidden)
+		}
+	}
+
+	if err := s.roleRepo.ReorderRoles(serverID, roleIDs); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.FindRolesByServer(serverID)
+}
+
+// AddMemberRole assigns a role below the highest role of the user to a
+// member of the server, and returns the member with its roles.
+func (s *RoleService) AddMemberRole(userID, serverID, memberID, roleID string) (*model.Member, error) {
+	member, err := s.checkMemberRole(userID, serverID, memberID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.AddMemberRole(member.ID, roleID); err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.FindMemberByID(member.ID)
+}
+
+// RemoveMemberRole takes a role below the highest role of the user away from
+// a member of the server, and returns the member with its remaining roles.
+func (s *RoleService) RemoveMemberRole(userID, serverID, memberID, roleID string) (*model.Member, error) {
+	member, err := s.checkMemberRole(userID, serverID, memberID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.RemoveMemberRole(member.ID, roleID); err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.FindMemberByID(member.ID)
+}
+
+// checkRole verifies that userID may manage roleID, which has to belong to
+// the server and sit below the highest role of the user.
+func (s *RoleService) checkRole(userID, serverID, roleID string) (*model.Role, *model.MemberPermissions, error) {
+	actor, err := s.permissions.Require(userID, serverID, model.PermManageRoles, "manage roles")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	role, err := s.roleRepo.FindRoleByID(roleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if role.ServerID != serverID {
+		return nil, nil, fmt.Errorf("role %w", model.ErrNotFound)
+	}
+
+	if !actor.Outranks(role.Position) {
+		return nil, nil, fmt.Errorf("%w: you cannot manage a role equal to or above your highest role", model.ErrForbidden)
+	}
+
+	return role, actor, nil
+}
+
+// checkMemberRole verifies that userID may give or take roleID from
+// memberID, which also needs the user to outrank the member.
+func (s *RoleService) checkMemberRole(userID, serverID, memberID, roleID string) (*model.Member, error) {
+	role, actor, err := s.checkRole(userID, serverID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Default {
+		return nil, fmt.Errorf("%w: every member holds the default role", model.ErrInvalid)
+	}
+
+	member, err := s.memberRepo.FindMemberByID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member.ServerID != serverID {
+		return nil, fmt.Errorf("member %w", model.ErrNotFound)
+	}
+
+	target, err := s.permissions.resolveMember(member)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Owner && !actor.Owner || !actor.Outranks(target.TopPosition) {
+		return nil, fmt.Errorf("%w: you cannot change the roles of a member with an equal or higher role", model.ErrForbidden)
+	}
+
+	return member, nil
+}
+
+// checkGrant makes sure perm only holds known permissions, all of which the
+// actor has.
+func checkGrant(actor *model.MemberPermissions, perm model.Permission) error {
+	if perm&^model.PermAll != 0 {
+		return fmt.Errorf("%w: unknown permissions", model.ErrInvalid)
+	}
+
+	if !actor.Has(perm) {
+		return fmt.Errorf("%w: you cannot grant permissions you do not have", model.ErrForbidden)
+	}
+
+	return nil
+}
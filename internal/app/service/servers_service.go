@@ -1,15 +1,22 @@
 package service
 
 import (
+	"fmt"
+
 	"github.com/razaq-himawan/chat-app-api/internal/app/model"
 )
 
 type ServerService struct {
-	serverRepo model.ServerRepository
+	serverRepo   model.ServerRepository
+	memberRepo   model.MemberRepository
+	channelRepo  model.ChannelRepository
+	categoryRepo model.CategoryRepository
+	roleRepo     model.RoleRepository
+	permissions  *PermissionResolver
 }
 
-func NewServerService(serverRepo model.ServerRepository) *ServerService {
-	return &ServerService{serverRepo: serverRepo}
+func NewServerService(serverRepo model.ServerRepository, memberRepo model.MemberRepository, channelRepo model.ChannelRepository, categoryRepo model.CategoryRepository, roleRepo model.RoleRepository, permissions *PermissionResolver) *ServerService {
+	return &ServerService{serverRepo: serverRepo, memberRepo: memberRepo, channelRepo: channelRepo, categoryRepo: categoryRepo, roleRepo: roleRepo, permissions: permissions}
 }
 
 func (s *ServerService) CreateServerWithMembersAndChannels(createServerPayload model.CreateServerPayload, userID string) (*model.ServerModel, error) {
@@ -22,3 +29,124 @@ func (s *ServerService) CreateServerWithMembersAndChannels(createServerPayload m
 	}
 	return server, nil
 }
+
+func (s *ServerService) GetUserServers(userID string) ([]model.ServerModel, error) {
+	return s.serverRepo.FindServersByUser(userID)
+}
+
+// GetServer returns a server the user is a member of with the tree of the
+// channels it may view, with unread counts for the user, its members and its
+// roles.
+func (s *ServerService) GetServer(userID, serverID string) (*model.ServerModel, error) {
+	permissions, err := s.permissions.Resolve(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := s.serverRepo.FindServerByID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := s.channelRepo.FindChannelsByServer(serverID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err = s.permissions.VisibleChannels(permissions, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryRepo.FindCategoriesByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	server.Channel, server.Categories = channelTree(channels, categories)
+
+	server.Members, err = s.memberRepo.FindMembersByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	server.Roles, err = s.roleRepo.FindRolesByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return server, nil
+}
+
+// UpdateServer renames a server or changes its icon, which only members
+// allowed to manage the server may do. The server is returned with its
+// members so they can be notified.
+func (s *ServerService) UpdateServer(userID, serverID string, updateServerPayload model.UpdateServerPayload) (*model.ServerModel, error) {
+	if updateServerPayload.Name == nil && updateServerPayload.IconURL == nil {
+		return nil, fmt.Errorf("%w: nothing to update", model.ErrInvalid)
+	}
+
+	if _, err := s.permissions.Require(userID, serverID, model.PermManageServer, "manage the server"); err != nil {
+		return nil, err
+	}
+
+	server, err := s.serverRepo.UpdateServer(serverID, updateServerPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	server.Members, err = s.memberRepo.FindMembersByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return server, nil
+}
+
+// DeleteServer deletes a server, which only its owner may do. The server is
+// returned with the members it had so they can be notified.
+func (s *ServerService) DeleteServer(userID, serverID string) (*model.ServerModel, error) {
+	server, err := s.serverRepo.FindServerByID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if server.UserID != userID {
+		return nil, fmt.Errorf("%w: only the owner can delete the server", model.ErrForbidden)
+	}
+
+	members, err := s.memberRepo.FindMembersByServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.serverRepo.DeleteServer(*server)
+	if err != nil {
+		return nil, err
+	}
+	deleted.Members = members
+
+	return deleted, nil
+}
+
+// channelTree groups ordered channels under their category. Channels without
+// one, or whose category is not listed, are returned on their own.
+func channelTree(channels []model.Channel, categories []model.Category) ([]model.Channel, []model.Category) {
+	byID := make(map[string]int, len(categories))
+	for i := range categories {
+		categories[i].Channels = []model.Channel{}
+		byID[categories[i].ID] = i
+	}
+
+	uncategorized := []model.Channel{}
+	for _, channel := range channels {
+		i, ok := byID[channel.CategoryID]
+		if !ok {
+			uncategorized = append(uncategorized, channel)
+			continue
+		}
+		categories[i].Channels = append(categories[i].Channels, channel)
+	}
+
+	return uncategorized, categories
+}
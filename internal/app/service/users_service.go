@@ -100,13 +100,19 @@ func (s *UserService) UpdateUserProfile(userID string, userUpdatePayload model.U
 		return nil, fmt.Errorf("invalid status")
 	}
 
+	// Picking ONLINE hands presence back to the connections.
+	manualStatus := userUpdatePayload.Status
+	if manualStatus == model.ONLINE {
+		manualStatus = ""
+	}
+
 	return s.userRepo.UpdateUserProfile(model.UserProfile{
-		Name:      userUpdatePayload.Name,
-		ImageURL:  userUpdatePayload.ImageURL,
-		BannerURL: userUpdatePayload.BannerURL,
-		Bio:       userUpdatePayload.Bio,
-		Status:    userUpdatePayload.Status,
-		UserID:    userID,
+		Name:         userUpdatePayload.Name,
+		ImageURL:     userUpdatePayload.ImageURL,
+		BannerURL:    userUpdatePayload.BannerURL,
+		Bio:          userUpdatePayload.Bio,
+		ManualStatus: manualStatus,
+		UserID:       userID,
 	})
 }
 
@@ -20,24 +20,62 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Last-Event-ID"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
 	db := s.db.GetDB()
+	wsServer := s.ws
 
 	userRepository := repository.NewUserRepository(db)
 	userService := service.NewUserService(userRepository)
-	userHandler := handler.NewUserHandler(userService)
+	presenceService := service.NewPresenceService(userRepository)
+	handler.RegisterPresenceHooks(presenceService, wsServer)
+	userHandler := handler.NewUserHandler(userService, presenceService, wsServer)
 
 	serverRepository := repository.NewServerRepository(db)
-	serverService := service.NewServerService(serverRepository)
-	serverHandler := handler.NewServerHandler(serverService)
+	memberRepository := repository.NewMemberRepository(db)
+	channelRepository := repository.NewChannelRepository(db)
+	categoryRepository := repository.NewCategoryRepository(db)
+	roleRepository := repository.NewRoleRepository(db)
+	permissionResolver := service.NewPermissionResolver(serverRepository, memberRepository, roleRepository, channelRepository)
+
+	serverService := service.NewServerService(serverRepository, memberRepository, channelRepository, categoryRepository, roleRepository, permissionResolver)
+	serverHandler := handler.NewServerHandler(serverService, wsServer)
+
+	memberService := service.NewMemberService(memberRepository, serverRepository, permissionResolver)
+	memberHandler := handler.NewMemberHandler(memberService, wsServer)
+
+	roleService := service.NewRoleService(roleRepository, memberRepository, permissionResolver)
+	roleHandler := handler.NewRoleHandler(roleService, memberService, wsServer)
+
+	inviteRepository := repository.NewInviteRepository(db)
+	inviteService := service.NewInviteService(inviteRepository, serverRepository, memberRepository, permissionResolver)
+	inviteHandler := handler.NewInviteHandler(inviteService)
+
+	categoryService := service.NewCategoryService(categoryRepository, permissionResolver)
+	categoryHandler := handler.NewCategoryHandler(categoryService, memberService, wsServer)
+
+	channelService := service.NewChannelService(channelRepository, categoryRepository, roleRepository, permissionResolver)
+	channelHandler := handler.NewChannelHandler(channelService, memberService, wsServer)
+
+	messageRepository := repository.NewMessageRepository(db)
+	readStateRepository := repository.NewReadStateRepository(db)
+
+	conversationRepository := repository.NewConversationRepository(db)
+	conversationService := service.NewConversationService(conversationRepository, userRepository, messageRepository)
+	conversationHandler := handler.NewConversationHandler(conversationService, wsServer)
+
+	messageService := service.NewMessageService(messageRepository, conversationRepository, readStateRepository, permissionResolver)
+	messageHandler := handler.NewMessageHandler(messageService, wsServer)
+
+	wsHandler := handler.NewWebSocketHandler(wsServer, messageService, conversationService, channelService)
+	eventStreamHandler := handler.NewEventStreamHandler(wsServer)
 
 	r.Get("/health", s.healthHandler)
 
-	r.Get("/ws", handler.HandleWebSocket)
+	r.Get("/ws", wsHandler.HandleWebSocket)
 
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Post("/register", userHandler.HandleRegister)
@@ -47,6 +85,11 @@ func (s *Server) RegisterRoutes() http.Handler {
 		r.Group(func(r chi.Router) {
 			r.Use(auth.AuthJWT(userService))
 
+			r.Route("/events", func(r chi.Router) {
+				r.Get("/", eventStreamHandler.HandleEventStream)
+				r.Post("/{sessionID}", eventStreamHandler.HandleEventStreamOp)
+			})
+
 			r.Route("/user/{userID}", func(r chi.Router) {
 				r.Get("/", userHandler.HandleGetOneUser)
 				r.Put("/", userHandler.HandleUpdateUserProfile)
@@ -54,7 +97,76 @@ func (s *Server) RegisterRoutes() http.Handler {
 			})
 
 			r.Route("/server", func(r chi.Router) {
+				r.Get("/", serverHandler.HandleGetServers)
 				r.Post("/create", serverHandler.CreateServer)
+
+				r.Route("/{serverID}", func(r chi.Router) {
+					r.Get("/", serverHandler.HandleGetServer)
+					r.Patch("/", serverHandler.HandleUpdateServer)
+					r.Delete("/", serverHandler.HandleDeleteServer)
+					r.Get("/channels", channelHandler.HandleGetServerChannels)
+					r.Post("/channels", channelHandler.HandleCreateChannel)
+					r.Put("/channels/order", channelHandler.HandleReorderChannels)
+					r.Post("/categories", categoryHandler.HandleCreateCategory)
+					r.Put("/categories/order", categoryHandler.HandleReorderCategories)
+					r.Get("/invites", inviteHandler.HandleGetServerInvites)
+					r.Post("/invites", inviteHandler.HandleCreateInvite)
+					r.Post("/invites/regenerate", inviteHandler.HandleRegenerateDefaultInvite)
+					r.Delete("/invites/{code}", inviteHandler.HandleRevokeInvite)
+					r.Post("/leave", memberHandler.HandleLeaveServer)
+					r.Delete("/members/{memberID}", memberHandler.HandleKickMember)
+					r.Post("/members/{memberID}/ban", memberHandler.HandleBanMember)
+					r.Put("/members/{memberID}/roles/{roleID}", roleHandler.HandleAddMemberRole)
+					r.Delete("/members/{memberID}/roles/{roleID}", roleHandler.HandleRemoveMemberRole)
+					r.Get("/roles", roleHandler.HandleGetServerRoles)
+					r.Post("/roles", roleHandler.HandleCreateRole)
+					r.Put("/roles/order", roleHandler.HandleReorderRoles)
+					r.Patch("/roles/{roleID}", roleHandler.HandleUpdateRole)
+					r.Delete("/roles/{roleID}", roleHandler.HandleDeleteRole)
+				})
+			})
+
+			r.Route("/invite/{code}", func(r chi.Router) {
+				r.Get("/", inviteHandler.HandlePreviewInvite)
+				r.Post("/accept", inviteHandler.HandleAcceptInvite)
+			})
+
+			r.Route("/category/{categoryID}", func(r chi.Router) {
+				r.Patch("/", categoryHandler.HandleUpdateCategory)
+				r.Delete("/", categoryHandler.HandleDeleteCategory)
+			})
+
+			r.Route("/channel/{channelID}", func(r chi.Router) {
+				r.Patch("/", channelHandler.HandleUpdateChannel)
+				r.Delete("/", channelHandler.HandleDeleteChannel)
+				r.Post("/move", channelHandler.HandleMoveChannel)
+				r.Get("/overwrites", channelHandler.HandleGetChannelOverwrites)
+				r.Put("/overwrites/{roleID}", channelHandler.HandleSetChannelOverwrite)
+				r.Delete("/overwrites/{roleID}", channelHandler.HandleDeleteChannelOverwrite)
+				r.Get("/messages", messageHandler.HandleGetChannelMessages)
+				r.Post("/messages", messageHandler.HandleCreateChannelMessage)
+			})
+
+			r.Route("/conversation", func(r chi.Router) {
+				r.Get("/", conversationHandler.HandleGetConversations)
+				r.Post("/", conversationHandler.HandleGetOrCreateConversation)
+				r.Post("/group", conversationHandler.HandleCreateGroupConversation)
+
+				r.Route("/{conversationID}", func(r chi.Router) {
+					r.Get("/", conversationHandler.HandleGetConversation)
+					r.Patch("/", conversationHandler.HandleRenameConversation)
+					r.Get("/messages", messageHandler.HandleGetConversationMessages)
+					r.Post("/messages", messageHandler.HandleCreateConversationMessage)
+					r.Post("/leave", conversationHandler.HandleLeaveConversation)
+					r.Post("/participants", conversationHandler.HandleAddParticipants)
+					r.Delete("/participants/{userID}", conversationHandler.HandleRemoveParticipant)
+				})
+			})
+
+			r.Route("/message/{messageID}", func(r chi.Router) {
+				r.Patch("/", messageHandler.HandleUpdateMessage)
+				r.Delete("/", messageHandler.HandleDeleteMessage)
+				r.Post("/ack", messageHandler.HandleAckMessage)
 			})
 
 		})
@@ -64,6 +176,11 @@ func (s *Server) RegisterRoutes() http.Handler {
 }
 
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	jsonResp, _ := json.Marshal(s.db.Health())
+	health := s.db.Health()
+	for key, value := range s.ws.Stats() {
+		health["ws_"+key] = value
+	}
+
+	jsonResp, _ := json.Marshal(health)
 	_, _ = w.Write(jsonResp)
 }
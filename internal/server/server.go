@@ -10,20 +10,23 @@ import (
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/razaq-himawan/chat-app-api/internal/database"
+	"github.com/razaq-himawan/chat-app-api/internal/websocket"
 )
 
 type Server struct {
 	port int
 
 	db database.Service
+	ws *websocket.WebSocketServer
 }
 
-func NewServer() *http.Server {
+func NewServer(wsServer *websocket.WebSocketServer) *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	NewServer := &Server{
 		port: port,
 
 		db: database.New(),
+		ws: wsServer,
 	}
 
 	// Declare Server config
@@ -0,0 +1,200 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coder/websocket"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes envelopes for the wire. Clients pick one with the
+// Sec-WebSocket-Protocol header, or the encoding query parameter when they
+// cannot set it, and JSON is used otherwise. Payloads stay JSON inside the
+// hub, a codec converts them once per event through WSEnvelope.EncodedData.
+type Codec interface {
+	Name() string
+	MessageType() websocket.MessageType
+	Encode(env *model.WSEnvelope) ([]byte, error)
+	Decode(frame []byte, env *model.WSEnvelope) error
+}
+
+var (
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+// codecs is in order of preference when a client offers several
+// subprotocols.
+var codecs = []Codec{MsgpackCodec, JSONCodec}
+
+// CodecByName returns the codec registered under name, which doubles as its
+// subprotocol.
+func CodecByName(name string) (Codec, bool) {
+	for _, codec := range codecs {
+		if strings.EqualFold(codec.Name(), name) {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// AcceptOptions offers every codec as a subprotocol and permessage-deflate as
+// configured.
+func (s *WebSocketServer) AcceptOptions() *websocket.AcceptOptions {
+	subprotocols := make([]string, 0, len(codecs))
+	for _, codec := range codecs {
+		subprotocols = append(subprotocols, codec.Name())
+	}
+
+	mode := websocket.CompressionDisabled
+	switch s.config.Compression {
+	case CompressionContextTakeover:
+		mode = websocket.CompressionContextTakeover
+	case CompressionNoContextTakeover:
+		mode = websocket.CompressionNoContextTakeover
+	}
+
+	return &websocket.AcceptOptions{
+		Subprotocols:         subprotocols,
+		CompressionMode:      mode,
+		CompressionThreshold: s.config.CompressionThreshold,
+	}
+}
+
+// RequestedCodec resolves the codec asked for by the encoding query
+// parameter, used when the upgrade negotiated no subprotocol.
+func RequestedCodec(r *http.Request) (Codec, error) {
+	name := r.URL.Query().Get("encoding")
+	if name == "" {
+		return JSONCodec, nil
+	}
+
+	codec, ok := CodecByName(name)
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return codec, nil
+}
+
+func (s *WebSocketServer) codecOf(client *model.WebSocketUser) Codec {
+	if codec, ok := CodecByName(client.Encoding); ok {
+		return codec
+	}
+	return JSONCodec
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) MessageType() websocket.MessageType { return websocket.MessageText }
+
+func (jsonCodec) Encode(env *model.WSEnvelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+func (jsonCodec) Decode(frame []byte, env *model.WSEnvelope) error {
+	return json.Unmarshal(frame, env)
+}
+
+// msgpackEnvelope is WSEnvelope with the same keys, carrying its data as
+// MessagePack.
+type msgpackEnvelope struct {
+	Version int                `msgpack:"v"`
+	Op      model.WSOp         `msgpack:"op"`
+	Type    model.WSEventType  `msgpack:"t,omitempty"`
+	Seq     int64              `msgpack:"seq,omitempty"`
+	Data    msgpack.RawMessage `msgpack:"d,omitempty"`
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) MessageType() websocket.MessageType { return websocket.MessageBinary }
+
+func (c msgpackCodec) Encode(env *model.WSEnvelope) ([]byte, error) {
+	data, err := env.EncodedData(c.Name(), jsonToMsgpack)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(msgpackEnvelope{
+		Version: env.Version,
+		Op:      env.Op,
+		Type:    env.Type,
+		Seq:     env.Seq,
+		Data:    data,
+	})
+}
+
+func (msgpackCodec) Decode(frame []byte, env *model.WSEnvelope) error {
+	var wire msgpackEnvelope
+	if err := msgpack.Unmarshal(frame, &wire); err != nil {
+		return err
+	}
+
+	env.Version = wire.Version
+	env.Op = wire.Op
+	env.Type = wire.Type
+	env.Seq = wire.Seq
+
+	if len(wire.Data) == 0 {
+		return nil
+	}
+
+	var data any
+	if err := msgpack.Unmarshal(wire.Data, &data); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	env.Data = raw
+	return nil
+}
+
+func jsonToMsgpack(raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(withNumbers(data))
+}
+
+// withNumbers turns the json.Number values of decoded JSON into integers
+// where they fit, floats otherwise, so they are not encoded as strings.
+func withNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = withNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = withNumbers(item)
+		}
+	}
+	return value
+}
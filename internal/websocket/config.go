@@ -0,0 +1,271 @@
+package websocket
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type OverflowPolicy string
+
+const (
+	// OverflowDrop discards events for a client whose send queue is full.
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowDisconnect closes a client whose send queue is full with
+	// StatusSlowConsumer so it can reconnect and resync.
+	OverflowDisconnect OverflowPolicy = "disconnect"
+)
+
+type PubSubBackend string
+
+const (
+	// PubSubMemory keeps events within the process, for a single instance.
+	PubSubMemory PubSubBackend = "memory"
+	// PubSubPostgres fans events out to every instance through Postgres
+	// LISTEN/NOTIFY.
+	PubSubPostgres PubSubBackend = "postgres"
+)
+
+type Compression string
+
+const (
+	CompressionDisabled Compression = "disabled"
+	// CompressionContextTakeover keeps a deflate window per connection,
+	// compressing better at the cost of memory.
+	CompressionContextTakeover Compression = "context_takeover"
+	// CompressionNoContextTakeover compresses every frame on its own.
+	CompressionNoContextTakeover Compression = "no_context_takeover"
+)
+
+type Config struct {
+	SendQueueSize  int
+	WriteTimeout   time.Duration
+	OverflowPolicy OverflowPolicy
+
+	// PingInterval is how often the server pings each client, PongTimeout
+	// how long it waits for the pong before treating the connection as dead.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	// IdleTimeout closes connections that sent no frame, heartbeat included,
+	// for that long. Zero disables it.
+	IdleTimeout time.Duration
+	// AutoIdleAfter marks a connection idle once it sent nothing but
+	// heartbeats for that long. Zero disables it.
+	AutoIdleAfter time.Duration
+
+	// ResumeWindow is how long a session survives its connection so the
+	// client can resume it, ReplayBufferSize how many dispatch events it
+	// keeps for that. A zero ResumeWindow disables resuming.
+	ResumeWindow     time.Duration
+	ReplayBufferSize int
+
+	// TypingThrottle is the minimum gap between two typing_start events for
+	// the same user and topic, TypingTimeout how long one lasts without a
+	// refresh before typing_stop is sent.
+	TypingThrottle time.Duration
+	TypingTimeout  time.Duration
+
+	// NonceWindow is how long a message nonce is remembered, so retries of
+	// the same send within it do not create a second message. Each instance
+	// only remembers the nonces it received.
+	NonceWindow time.Duration
+
+	// PubSub selects how events reach the other instances, PubSubChannel is
+	// the Postgres notification channel they share.
+	PubSub        PubSubBackend
+	PubSubChannel string
+
+	// Compression is the permessage-deflate mode used with clients that
+	// offer it, CompressionThreshold the smallest frame worth compressing,
+	// zero for the library default.
+	Compression          Compression
+	CompressionThreshold int
+
+	// ConnRateLimits caps how often each connection may send an op, and
+	// UserRateLimits all connections of a user together. Ops without a limit
+	// are not limited. A connection that got RateLimitStrikes rate_limited
+	// errors within RateLimitWindow is closed, zero strikes never closes it.
+	ConnRateLimits   map[model.WSOp]RateLimit
+	UserRateLimits   map[model.WSOp]RateLimit
+	RateLimitStrikes int
+	RateLimitWindow  time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		SendQueueSize:  256,
+		WriteTimeout:   10 * time.Second,
+		OverflowPolicy: OverflowDisconnect,
+		PingInterval:   30 * time.Second,
+		PongTimeout:    10 * time.Second,
+		IdleTimeout:    90 * time.Second,
+		AutoIdleAfter:  10 * time.Minute,
+
+		ResumeWindow:     2 * time.Minute,
+		ReplayBufferSize: 128,
+
+		TypingThrottle: 3 * time.Second,
+		TypingTimeout:  8 * time.Second,
+
+		NonceWindow: 5 * time.Minute,
+
+		PubSub:        PubSubMemory,
+		PubSubChannel: "hub_events",
+
+		Compression: CompressionNoContextTakeover,
+
+		ConnRateLimits: map[model.WSOp]RateLimit{
+			model.OpMessageCreate: {Count: 5, Per: 5 * time.Second},
+			model.OpMessageEdit:   {Count: 5, Per: 5 * time.Second},
+			model.OpMessageDelete: {Count: 5, Per: 5 * time.Second},
+			model.OpTyping:        {Count: 5, Per: time.Second},
+			model.OpSubscribe:     {Count: 50, Per: 10 * time.Second},
+		},
+		UserRateLimits: map[model.WSOp]RateLimit{
+			model.OpMessageCreate: {Count: 10, Per: 5 * time.Second},
+			model.OpMessageEdit:   {Count: 10, Per: 5 * time.Second},
+			model.OpMessageDelete: {Count: 10, Per: 5 * time.Second},
+			model.OpTyping:        {Count: 10, Per: time.Second},
+			model.OpSubscribe:     {Count: 100, Per: 10 * time.Second},
+		},
+		RateLimitStrikes: 20,
+		RateLimitWindow:  10 * time.Second,
+	}
+}
+
+// rateLimitedOps are the client ops whose limits can be set from the
+// environment.
+var rateLimitedOps = []model.WSOp{
+	model.OpHeartbeat,
+	model.OpSubscribe,
+	model.OpUnsubscribe,
+	model.OpMessageCreate,
+	model.OpMessageEdit,
+	model.OpMessageDelete,
+	model.OpTyping,
+	model.OpPresence,
+	model.OpReadAck,
+}
+
+// ConfigFromEnv overrides DefaultConfig with the WS_* environment variables.
+func ConfigFromEnv() Config {
+	config := DefaultConfig()
+
+	intFromEnv("WS_SEND_QUEUE_SIZE", &config.SendQueueSize, false)
+	intFromEnv("WS_REPLAY_BUFFER_SIZE", &config.ReplayBufferSize, true)
+	intFromEnv("WS_COMPRESSION_THRESHOLD", &config.CompressionThreshold, true)
+	intFromEnv("WS_RATE_LIMIT_STRIKES", &config.RateLimitStrikes, true)
+
+	durationFromEnv("WS_WRITE_TIMEOUT", &config.WriteTimeout, false)
+	durationFromEnv("WS_PING_INTERVAL", &config.PingInterval, false)
+	durationFromEnv("WS_PONG_TIMEOUT", &config.PongTimeout, false)
+	durationFromEnv("WS_IDLE_TIMEOUT", &config.IdleTimeout, true)
+	durationFromEnv("WS_AUTO_IDLE_AFTER", &config.AutoIdleAfter, true)
+	durationFromEnv("WS_RESUME_WINDOW", &config.ResumeWindow, true)
+	durationFromEnv("WS_TYPING_THROTTLE", &config.TypingThrottle, true)
+	durationFromEnv("WS_TYPING_TIMEOUT", &config.TypingTimeout, false)
+	durationFromEnv("WS_NONCE_WINDOW", &config.NonceWindow, false)
+	durationFromEnv("WS_RATE_LIMIT_WINDOW", &config.RateLimitWindow, false)
+
+	for _, op := range rateLimitedOps {
+		name := strings.ToUpper(string(op))
+		rateLimitFromEnv("WS_RATE_LIMIT_"+name, config.ConnRateLimits, op)
+		rateLimitFromEnv("WS_USER_RATE_LIMIT_"+name, config.UserRateLimits, op)
+	}
+
+	if v := os.Getenv("WS_OVERFLOW_POLICY"); v != "" {
+		switch policy := OverflowPolicy(v); policy {
+		case OverflowDrop, OverflowDisconnect:
+			config.OverflowPolicy = policy
+		default:
+			log.Printf("invalid WS_OVERFLOW_POLICY %q, using %s", v, config.OverflowPolicy)
+		}
+	}
+
+	if v := os.Getenv("WS_PUBSUB"); v != "" {
+		switch backend := PubSubBackend(v); backend {
+		case PubSubMemory, PubSubPostgres:
+			config.PubSub = backend
+		default:
+			log.Printf("invalid WS_PUBSUB %q, using %s", v, config.PubSub)
+		}
+	}
+
+	if v := os.Getenv("WS_PUBSUB_CHANNEL"); v != "" {
+		config.PubSubChannel = v
+	}
+
+	if v := os.Getenv("WS_COMPRESSION"); v != "" {
+		switch compression := Compression(v); compression {
+		case CompressionDisabled, CompressionContextTakeover, CompressionNoContextTakeover:
+			config.Compression = compression
+		default:
+			log.Printf("invalid WS_COMPRESSION %q, using %s", v, config.Compression)
+		}
+	}
+
+	return config
+}
+
+// rateLimitFromEnv reads a limit written as count/duration, such as 5/1s,
+// with a count of 0 removing it.
+func rateLimitFromEnv(key string, target map[model.WSOp]RateLimit, op model.WSOp) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+
+	count, per, _ := strings.Cut(v, "/")
+	limit := RateLimit{}
+
+	var err error
+	limit.Count, err = strconv.Atoi(count)
+	if err == nil && limit.Count > 0 {
+		limit.Per, err = time.ParseDuration(per)
+	}
+	if err != nil || limit.Count < 0 || (limit.Count > 0 && limit.Per <= 0) {
+		log.Printf("invalid %s %q, using %d/%s", key, v, target[op].Count, target[op].Per)
+		return
+	}
+
+	if limit.Count == 0 {
+		delete(target, op)
+		return
+	}
+	target[op] = limit
+}
+
+func intFromEnv(key string, target *int, allowZero bool) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || (n == 0 && !allowZero) {
+		log.Printf("invalid %s %q, using %d", key, v, *target)
+		return
+	}
+
+	*target = n
+}
+
+func durationFromEnv(key string, target *time.Duration, allowZero bool) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 || (d == 0 && !allowZero) {
+		log.Printf("invalid %s %q, using %s", key, v, *target)
+		return
+	}
+
+	*target = d
+}
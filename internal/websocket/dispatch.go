@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+// OpHandlerFunc handles a single client op. Returning an *OpError sends that
+// error code back to the client, the model sentinel errors map to their codes
+// and any other error is reported as internal. On success the returned ack,
+// if any, is sent with the op's seq filled in.
+type OpHandlerFunc func(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error)
+
+type OpError struct {
+	Code    model.WSErrorCode
+	Message string
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func NewOpError(code model.WSErrorCode, format string, args ...any) *OpError {
+	return &OpError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (s *WebSocketServer) HandleOp(op model.WSOp, fn OpHandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[op] = fn
+}
+
+// Dispatch decodes a raw client frame with the client's codec and routes it
+// to the registered op handler, replying with an ack or an error envelope.
+func (s *WebSocketServer) Dispatch(ctx context.Context, client *model.WebSocketUser, frame []byte) {
+	client.Touch()
+
+	var env model.WSEnvelope
+	if err := s.codecOf(client).Decode(frame, &env); err != nil {
+		s.SendError(client, 0, model.ErrCodeBadRequest, "malformed envelope")
+		return
+	}
+
+	if env.Version != model.WSProtocolVersion {
+		s.SendError(client, env.Seq, model.ErrCodeUnsupportedVersion, fmt.Sprintf("protocol version %d is not supported", env.Version))
+		return
+	}
+
+	s.mu.RLock()
+	fn, ok := s.handlers[env.Op]
+	s.mu.RUnlock()
+	if !ok {
+		s.SendError(client, env.Seq, model.ErrCodeUnknownOp, fmt.Sprintf("unknown op %q", env.Op))
+		return
+	}
+
+	if retryAfter, ok := s.limit(client, env.Op); !ok {
+		s.SendRateLimited(client, env.Seq, env.Op, retryAfter)
+		return
+	}
+
+	if env.Op != model.OpHeartbeat && env.Op != model.OpPresence && client.MarkActive() {
+		s.refreshPresence(client.UserID)
+	}
+
+	ack, err := fn(ctx, client, &env)
+	if err != nil {
+		var opErr *OpError
+		switch {
+		case errors.As(err, &opErr):
+			s.SendError(client, env.Seq, opErr.Code, opErr.Message)
+			return
+		case errors.Is(err, model.ErrForbidden):
+			s.SendError(client, env.Seq, model.ErrCodeForbidden, err.Error())
+			return
+		case errors.Is(err, model.ErrNotFound):
+			s.SendError(client, env.Seq, model.ErrCodeNotFound, err.Error())
+			return
+		case errors.Is(err, model.ErrInvalid):
+			s.SendError(client, env.Seq, model.ErrCodeBadRequest, err.Error())
+			return
+		case errors.Is(err, model.ErrConflict):
+			s.SendError(client, env.Seq, model.ErrCodeDuplicate, err.Error())
+			return
+		}
+		log.Printf("Error handling op %s for user %s: %v", env.Op, client.UserID, err)
+		s.SendError(client, env.Seq, model.ErrCodeInternal, "internal error")
+		return
+	}
+
+	if ack == nil {
+		ack = &model.WSAckPayload{}
+	}
+	ack.Seq = env.Seq
+	if ack.Seq != 0 || ack.Nonce != "" {
+		s.SendAck(client, *ack)
+	}
+}
+
+func (s *WebSocketServer) SendAck(client *model.WebSocketUser, ack model.WSAckPayload) {
+	env, err := model.NewWSEnvelope(model.OpAck, "", ack)
+	if err != nil {
+		log.Printf("Error building ack envelope: %v", err)
+		return
+	}
+	s.SendToClient(client, env)
+}
+
+func (s *WebSocketServer) SendError(client *model.WebSocketUser, seq int64, code model.WSErrorCode, message string) {
+	s.sendError(client, model.WSErrorPayload{
+		Seq:     seq,
+		Code:    code,
+		Message: message,
+	})
+}
+
+// SendRateLimited tells a client its op was dropped and when it may send it
+// again.
+func (s *WebSocketServer) SendRateLimited(client *model.WebSocketUser, seq int64, op model.WSOp, retryAfter time.Duration) {
+	s.sendError(client, model.WSErrorPayload{
+		Seq:        seq,
+		Code:       model.ErrCodeRateLimited,
+		Message:    fmt.Sprintf("too many %s ops", op),
+		RetryAfter: max(retryAfter.Milliseconds(), 1),
+	})
+}
+
+func (s *WebSocketServer) sendError(client *model.WebSocketUser, payload model.WSErrorPayload) {
+	env, err := model.NewWSEnvelope(model.OpError, "", payload)
+	if err != nil {
+		log.Printf("Error building error envelope: %v", err)
+		return
+	}
+	s.SendToClient(client, env)
+}
+
+// DecodePayload unmarshals and validates the envelope data into payload.
+func DecodePayload(env *model.WSEnvelope, payload any) error {
+	if len(env.Data) == 0 {
+		return NewOpError(model.ErrCodeBadRequest, "missing payload")
+	}
+	if err := json.Unmarshal(env.Data, payload); err != nil {
+		return NewOpError(model.ErrCodeBadRequest, "malformed payload")
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		return NewOpError(model.ErrCodeBadRequest, "invalid payload %v", err)
+	}
+	return nil
+}
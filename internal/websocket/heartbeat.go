@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// StatusIdleTimeout is the close code sent to clients that stopped sending
+// heartbeats.
+const StatusIdleTimeout websocket.StatusCode = 4009
+
+// Heartbeat pings the client every PingInterval and enforces IdleTimeout
+// until ctx is done. A dead or idle connection is closed, which ends the read
+// loop and unregisters the client through the usual Disconnect path.
+func (s *WebSocketServer) Heartbeat(ctx context.Context, client *model.WebSocketUser) {
+	ticker := time.NewTicker(s.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if s.config.IdleTimeout > 0 && time.Since(client.LastSeen()) > s.config.IdleTimeout {
+			log.Printf("Closing idle connection %s of user %s", client.ID, client.UserID)
+			client.Conn.Close(StatusIdleTimeout, "idle timeout")
+			return
+		}
+
+		s.autoIdle(client)
+
+		pingCtx, cancel := context.WithTimeout(ctx, s.config.PongTimeout)
+		err := client.Conn.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Connection %s of user %s missed a pong: %v", client.ID, client.UserID, err)
+				client.Conn.CloseNow()
+			}
+			return
+		}
+	}
+}
+
+// autoIdle marks a client idle once it has not been active for AutoIdleAfter.
+func (s *WebSocketServer) autoIdle(client *model.WebSocketUser) {
+	if s.config.AutoIdleAfter > 0 && time.Since(client.LastActive()) > s.config.AutoIdleAfter && client.SetIdle(true) {
+		s.refreshPresence(client.UserID)
+	}
+}
+
+// heartbeatInterval is how often clients are asked to send a heartbeat op,
+// leaving them two attempts before IdleTimeout.
+func (s *WebSocketServer) heartbeatInterval() time.Duration {
+	if s.config.IdleTimeout == 0 {
+		return s.config.PingInterval
+	}
+	return s.config.IdleTimeout / 2
+}
+
+func (s *WebSocketServer) handleHeartbeat(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	ack, err := model.NewWSEnvelope(model.OpHeartbeatAck, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	s.SendToClient(client, ack)
+	return nil, nil
+}
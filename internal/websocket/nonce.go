@@ -0,0 +1,84 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+type nonceKey struct {
+	userID string
+	nonce  string
+}
+
+type nonceEntry struct {
+	messageID string
+	expiresAt time.Time
+}
+
+// nonceCache remembers the message nonces each user sent during the last
+// NonceWindow. An entry without a message id is a send still in flight.
+//
+// The cache lives in the memory of each instance and nonces are not stored
+// with the messages, so the guarantee is per node: a retry that reaches
+// another instance behind the load balancer creates a second message.
+type nonceCache struct {
+	mu        sync.Mutex
+	entries   map[nonceKey]nonceEntry
+	lastSweep time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{entries: make(map[nonceKey]nonceEntry)}
+}
+
+// ClaimNonce reserves nonce for a send by userID. When the nonce was already
+// used within the window it returns false along with the id of the message
+// it created, which is empty while that send is still in flight.
+func (s *WebSocketServer) ClaimNonce(userID, nonce string) (string, bool) {
+	c := s.nonces
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) > s.config.NonceWindow {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	key := nonceKey{userID: userID, nonce: nonce}
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expiresAt) {
+		return entry.messageID, false
+	}
+
+	c.entries[key] = nonceEntry{expiresAt: now.Add(s.config.NonceWindow)}
+	return "", true
+}
+
+// ResolveNonce records the message a claimed nonce created.
+func (s *WebSocketServer) ResolveNonce(userID, nonce, messageID string) {
+	c := s.nonces
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[nonceKey{userID: userID, nonce: nonce}] = nonceEntry{
+		messageID: messageID,
+		expiresAt: time.Now().Add(s.config.NonceWindow),
+	}
+}
+
+// ReleaseNonce forgets a claimed nonce whose send failed, so it can be
+// retried.
+func (s *WebSocketServer) ReleaseNonce(userID, nonce string) {
+	c := s.nonces
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, nonceKey{userID: userID, nonce: nonce})
+}
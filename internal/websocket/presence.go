@@ -0,0 +1,216 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// PresenceFunc is told whenever the status derived from a user's sessions
+// changes: OFFLINE once the last one is gone, IDLE while every one of them is
+// idle and ONLINE otherwise. Detached sessions count until they expire so a
+// flaky connection does not flap between online and offline. Sessions held on
+// other instances count too, but only the instance whose own sessions caused
+// a change reports it.
+type PresenceFunc func(userID string, status model.ProfileStatus)
+
+var presenceRank = map[model.ProfileStatus]int{
+	model.OFFLINE: 0,
+	model.IDLE:    1,
+	model.ONLINE:  2,
+}
+
+// presenceTracker combines the status of each user on this instance with the
+// ones announced by other instances. It coalesces changes and reports them
+// from a single goroutine, so a slow PresenceFunc never holds up the hub loop
+// and only the latest status of each user is delivered.
+type presenceTracker struct {
+	mu       sync.Mutex
+	notify   PresenceFunc
+	local    map[string]model.ProfileStatus
+	remote   map[string]map[string]model.ProfileStatus
+	last     map[string]model.ProfileStatus
+	pending  map[string]model.ProfileStatus
+	announce map[string]model.ProfileStatus
+	wake     chan struct{}
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{
+		local:    make(map[string]model.ProfileStatus),
+		remote:   make(map[string]map[string]model.ProfileStatus),
+		last:     make(map[string]model.ProfileStatus),
+		pending:  make(map[string]model.ProfileStatus),
+		announce: make(map[string]model.ProfileStatus),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+func (t *presenceTracker) setLocal(userID string, status model.ProfileStatus) {
+	t.mu.Lock()
+	if current, ok := t.local[userID]; (ok && current == status) || (!ok && status == model.OFFLINE) {
+		t.mu.Unlock()
+		return
+	}
+
+	setStatus(t.local, userID, status)
+	t.announce[userID] = status
+
+	if combined := t.combinedLocked(userID); combined != t.lastLocked(userID) {
+		setStatus(t.last, userID, combined)
+		t.pending[userID] = combined
+	}
+	t.mu.Unlock()
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (t *presenceTracker) setRemote(nodeID, userID string, status model.ProfileStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nodes, ok := t.remote[userID]
+	if !ok {
+		nodes = make(map[string]model.ProfileStatus)
+		t.remote[userID] = nodes
+	}
+	setStatus(nodes, nodeID, status)
+	if len(nodes) == 0 {
+		delete(t.remote, userID)
+	}
+
+	setStatus(t.last, userID, t.combinedLocked(userID))
+}
+
+func (t *presenceTracker) combinedLocked(userID string) model.ProfileStatus {
+	combined := t.local[userID]
+	if combined == "" {
+		combined = model.OFFLINE
+	}
+	for _, status := range t.remote[userID] {
+		if presenceRank[status] > presenceRank[combined] {
+			combined = status
+		}
+	}
+	return combined
+}
+
+func (t *presenceTracker) lastLocked(userID string) model.ProfileStatus {
+	if last, ok := t.last[userID]; ok {
+		return last
+	}
+	return model.OFFLINE
+}
+
+// setStatus stores status under key, keeping OFFLINE implicit.
+func setStatus(statuses map[string]model.ProfileStatus, key string, status model.ProfileStatus) {
+	if status == model.OFFLINE {
+		delete(statuses, key)
+	} else {
+		statuses[key] = status
+	}
+}
+
+// run shares the local changes with the other instances through announce,
+// and reports combined changes to the PresenceFunc, until stop is closed.
+func (t *presenceTracker) run(stop <-chan struct{}, announce func(userID string, status model.ProfileStatus)) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.wake:
+		}
+
+		t.mu.Lock()
+		local, combined, notify := t.announce, t.pending, t.notify
+		t.announce = make(map[string]model.ProfileStatus)
+		t.pending = make(map[string]model.ProfileStatus)
+		t.mu.Unlock()
+
+		for userID, status := range local {
+			announce(userID, status)
+		}
+
+		if notify == nil {
+			continue
+		}
+		for userID, status := range combined {
+			notify(userID, status)
+		}
+	}
+}
+
+// OnPresence sets the function told about presence changes.
+func (s *WebSocketServer) OnPresence(fn PresenceFunc) {
+	s.presence.mu.Lock()
+	defer s.presence.mu.Unlock()
+
+	s.presence.notify = fn
+}
+
+// ConnectionStatus is the status derived from the sessions userID holds on
+// every instance, as last announced by them.
+func (s *WebSocketServer) ConnectionStatus(userID string) model.ProfileStatus {
+	s.presence.mu.Lock()
+	defer s.presence.mu.Unlock()
+
+	return s.presence.combinedLocked(userID)
+}
+
+func (s *WebSocketServer) refreshPresence(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshPresenceLocked(userID)
+}
+
+func (s *WebSocketServer) refreshPresenceLocked(userID string) {
+	sessions := s.sessionsOfLocked(userID)
+
+	status := model.OFFLINE
+	for _, client := range sessions {
+		if !client.IsIdle() {
+			status = model.ONLINE
+			break
+		}
+		status = model.IDLE
+	}
+
+	s.presence.setLocal(userID, status)
+}
+
+func (s *WebSocketServer) announcePresence(userID string, status model.ProfileStatus) {
+	err := s.send(&Event{Kind: KindPresence, NodeID: s.nodeID, UserID: userID, Presence: status})
+	if err != nil {
+		log.Printf("Error announcing presence of user %s: %v", userID, err)
+	}
+}
+
+// handlePresence lets a client report that its user went idle or came back,
+// for instance when the app loses or regains focus.
+func (s *WebSocketServer) handlePresence(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) (*model.WSAckPayload, error) {
+	var payload model.WSPresencePayload
+	if err := DecodePayload(env, &payload); err != nil {
+		return nil, err
+	}
+
+	var idle bool
+	switch payload.Status {
+	case model.ONLINE:
+		idle = false
+	case model.IDLE:
+		idle = true
+	default:
+		return nil, NewOpError(model.ErrCodeBadRequest, "status must be %s or %s", model.ONLINE, model.IDLE)
+	}
+
+	if client.SetIdle(idle) {
+		s.refreshPresence(client.UserID)
+	}
+	return nil, nil
+}
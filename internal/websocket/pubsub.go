@@ -0,0 +1,53 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+)
+
+// PubSub carries hub events between API instances. Every event published by
+// any instance, this one included, is handed to the functions subscribed on
+// every instance, in the order each instance published them.
+type PubSub interface {
+	Publish(ctx context.Context, event *Event) error
+	// Subscribe delivers events to fn until ctx is done or the subscription
+	// breaks, in which case the hub subscribes again.
+	Subscribe(ctx context.Context, fn func(*Event)) error
+}
+
+// MemoryPubSub delivers events within the process, for a single instance.
+type MemoryPubSub struct {
+	mu          sync.RWMutex
+	subscribers map[int]func(*Event)
+	next        int
+}
+
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{subscribers: make(map[int]func(*Event))}
+}
+
+func (p *MemoryPubSub) Publish(ctx context.Context, event *Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, fn := range p.subscribers {
+		fn(event)
+	}
+	return nil
+}
+
+func (p *MemoryPubSub) Subscribe(ctx context.Context, fn func(*Event)) error {
+	p.mu.Lock()
+	id := p.next
+	p.next++
+	p.subscribers[id] = fn
+	p.mu.Unlock()
+
+	<-ctx.Done()
+
+	p.mu.Lock()
+	delete(p.subscribers, id)
+	p.mu.Unlock()
+
+	return nil
+}
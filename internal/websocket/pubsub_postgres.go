@@ -0,0 +1,115 @@
+package websocket
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// maxNotifyPayload stays under the 8000 byte limit Postgres puts on NOTIFY
+// payloads. Larger events are stored in hub_events and only their id is
+// sent, prefixed with hubEventRef.
+const (
+	maxNotifyPayload = 7900
+	hubEventRef      = "@"
+)
+
+// PostgresPubSub fans hub events out to every instance through
+// LISTEN/NOTIFY on the application database.
+type PostgresPubSub struct {
+	db      *sql.DB
+	channel string
+}
+
+func NewPostgresPubSub(db *sql.DB, channel string) *PostgresPubSub {
+	return &PostgresPubSub{db: db, channel: channel}
+}
+
+func (p *PostgresPubSub) Publish(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	notification := string(payload)
+	if len(payload) > maxNotifyPayload {
+		var id int64
+		query := "INSERT INTO hub_events (payload) VALUES ($1) RETURNING id"
+		if err := p.db.QueryRowContext(ctx, query, payload).Scan(&id); err != nil {
+			return fmt.Errorf("failed to store hub event: %v", err)
+		}
+		notification = fmt.Sprintf("%s%d", hubEventRef, id)
+
+		// Every instance fetched it long before then.
+		query = "DELETE FROM hub_events WHERE created_at < CURRENT_TIMESTAMP - INTERVAL '5 minutes'"
+		if _, err := p.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to clean up hub events: %v", err)
+		}
+	}
+
+	if _, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", p.channel, notification); err != nil {
+		return fmt.Errorf("failed to notify hub event: %v", err)
+	}
+
+	return nil
+}
+
+// Subscribe holds a connection of the pool for as long as it listens.
+func (p *PostgresPubSub) Subscribe(ctx context.Context, fn func(*Event)) error {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a listen connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "LISTEN "+pgx.Identifier{p.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", p.channel, err)
+	}
+	// Do not hand a listening connection back to the pool.
+	defer conn.ExecContext(context.Background(), "UNLISTEN *")
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+
+			event, err := p.decode(ctx, notification.Payload)
+			if err != nil {
+				log.Printf("Dropping hub event: %v", err)
+				continue
+			}
+			fn(event)
+		}
+	})
+}
+
+func (p *PostgresPubSub) decode(ctx context.Context, notification string) (*Event, error) {
+	payload := []byte(notification)
+
+	if id, ok := strings.CutPrefix(notification, hubEventRef); ok {
+		query := "SELECT payload FROM hub_events WHERE id = $1"
+		if err := p.db.QueryRowContext(ctx, query, id).Scan(&payload); err != nil {
+			return nil, fmt.Errorf("failed to fetch hub event %s: %v", id, err)
+		}
+	}
+
+	event := &Event{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("failed to decode hub event: %v", err)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"context"
+	"log"
+
+	"github.com/coder/websocket"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// StatusSlowConsumer is the close code sent to clients evicted because their
+// send queue overflowed.
+const StatusSlowConsumer websocket.StatusCode = 4008
+
+// NewClient builds a connection with a send queue and replay buffer sized
+// from the hub config. It speaks the codec negotiated as the subprotocol of
+// conn, if any.
+func (s *WebSocketServer) NewClient(id, userID string, conn *websocket.Conn) *model.WebSocketUser {
+	client := &model.WebSocketUser{
+		ID:               id,
+		UserID:           userID,
+		Conn:             conn,
+		Send:             make(chan *model.WSEnvelope, s.config.SendQueueSize),
+		Subscriptions:    make(map[string]string),
+		IsOnline:         true,
+		ReplayBufferSize: s.config.ReplayBufferSize,
+		Encoding:         JSONCodec.Name(),
+	}
+	if conn != nil && conn.Subprotocol() != "" {
+		client.Encoding = conn.Subprotocol()
+	}
+	client.Touch()
+	client.MarkActive()
+
+	return client
+}
+
+// WritePump is the only writer of a client connection. It drains the send
+// queue until ctx is done or a write fails, in which case the connection is
+// closed so the read loop unregisters it.
+func (s *WebSocketServer) WritePump(ctx context.Context, client *model.WebSocketUser) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case env := <-client.Send:
+			if err := s.writeEnvelope(ctx, client, env); err != nil {
+				log.Printf("Error writing to connection %s of user %s: %v", client.ID, client.UserID, err)
+				client.CloseNow()
+				return
+			}
+			s.stats.sent.Add(1)
+		}
+	}
+}
+
+func (s *WebSocketServer) writeEnvelope(ctx context.Context, client *model.WebSocketUser, env *model.WSEnvelope) error {
+	codec := s.codecOf(client)
+
+	frame, err := codec.Encode(env)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.WriteTimeout)
+	defer cancel()
+
+	return client.Conn.Write(ctx, codec.MessageType(), frame)
+}
+
+// enqueue hands an envelope to the client's write pump without blocking and
+// applies the overflow policy when the queue is full.
+func (s *WebSocketServer) enqueue(client *model.WebSocketUser, env *model.WSEnvelope) {
+	if !client.Enqueue(env) {
+		s.overflow(client)
+	}
+}
+
+// overflow applies the overflow policy to a client whose send queue is full.
+// Dropped dispatch events stay in the replay buffer, so an evicted client can
+// still resume.
+func (s *WebSocketServer) overflow(client *model.WebSocketUser) {
+	s.stats.dropped.Add(1)
+
+	if s.config.OverflowPolicy != OverflowDisconnect {
+		return
+	}
+
+	if client.MarkClosing() {
+		s.stats.slowConsumers.Add(1)
+		log.Printf("Evicting slow connection %s of user %s", client.ID, client.UserID)
+		go client.Close(StatusSlowConsumer, "slow consumer")
+	}
+}
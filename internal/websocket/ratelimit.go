@@ -0,0 +1,183 @@
+package websocket
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// StatusRateLimited is the close code sent to clients that kept sending ops
+// past their rate limits.
+const StatusRateLimited websocket.StatusCode = 4029
+
+// RateLimit allows Count ops every Per, in bursts of up to Count. A zero
+// Count disables it.
+type RateLimit struct {
+	Count int
+	Per   time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// refill adds the tokens earned since the last update, starting full.
+func (b *bucket) refill(limit RateLimit, now time.Time) {
+	capacity := float64(limit.Count)
+	if b.updated.IsZero() {
+		b.tokens = capacity
+	} else {
+		b.tokens = min(capacity, b.tokens+now.Sub(b.updated).Seconds()*limit.rate())
+	}
+	b.updated = now
+}
+
+// wait is how long until the bucket holds a whole token again.
+func (b *bucket) wait(limit RateLimit) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / limit.rate() * float64(time.Second))
+}
+
+func (l RateLimit) rate() float64 {
+	return float64(l.Count) / l.Per.Seconds()
+}
+
+type connOp struct {
+	client *model.WebSocketUser
+	op     model.WSOp
+}
+
+type userOp struct {
+	userID string
+	op     model.WSOp
+}
+
+type strikes struct {
+	count int
+	since time.Time
+}
+
+// rateLimiter holds a token bucket per connection and op, one per user and op
+// shared by all of the user's connections, and the rate limited ops counted
+// against each connection.
+type rateLimiter struct {
+	mu        sync.Mutex
+	conns     map[connOp]*bucket
+	users     map[userOp]*bucket
+	strikes   map[*model.WebSocketUser]*strikes
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		conns:   make(map[connOp]*bucket),
+		users:   make(map[userOp]*bucket),
+		strikes: make(map[*model.WebSocketUser]*strikes),
+	}
+}
+
+// limit takes a token for op from both the connection and the user bucket.
+// When either is empty nothing is taken, the connection gets a strike and
+// the time until it may retry is returned along with false. A connection
+// reaching RateLimitStrikes within RateLimitWindow is closed.
+func (s *WebSocketServer) limit(client *model.WebSocketUser, op model.WSOp) (time.Duration, bool) {
+	connLimit, limitConn := s.config.ConnRateLimits[op]
+	userLimit, limitUser := s.config.UserRateLimits[op]
+	limitConn = limitConn && connLimit.Count > 0
+	limitUser = limitUser && userLimit.Count > 0
+	if !limitConn && !limitUser {
+		return 0, true
+	}
+
+	l := s.limiter
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > time.Minute {
+		l.sweep(s.config, now)
+	}
+
+	var wait time.Duration
+	var connBucket, userBucket *bucket
+	if limitConn {
+		connBucket = bucketOf(l.conns, connOp{client: client, op: op})
+		connBucket.refill(connLimit, now)
+		wait = max(wait, connBucket.wait(connLimit))
+	}
+	if limitUser {
+		userBucket = bucketOf(l.users, userOp{userID: client.UserID, op: op})
+		userBucket.refill(userLimit, now)
+		wait = max(wait, userBucket.wait(userLimit))
+	}
+
+	if (connBucket == nil || connBucket.tokens >= 1) && (userBucket == nil || userBucket.tokens >= 1) {
+		if connBucket != nil {
+			connBucket.tokens--
+		}
+		if userBucket != nil {
+			userBucket.tokens--
+		}
+		return 0, true
+	}
+
+	s.stats.rateLimited.Add(1)
+	s.strike(client, now)
+	return wait, false
+}
+
+func (s *WebSocketServer) strike(client *model.WebSocketUser, now time.Time) {
+	if s.config.RateLimitStrikes == 0 {
+		return
+	}
+
+	l := s.limiter
+	st, ok := l.strikes[client]
+	if !ok || now.Sub(st.since) > s.config.RateLimitWindow {
+		st = &strikes{since: now}
+		l.strikes[client] = st
+	}
+	st.count++
+
+	if st.count >= s.config.RateLimitStrikes && client.MarkClosing() {
+		log.Printf("Closing connection %s of user %s for exceeding its rate limits", client.ID, client.UserID)
+		go client.Close(StatusRateLimited, "rate limited")
+	}
+}
+
+func bucketOf[K comparable](buckets map[K]*bucket, key K) *bucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = &bucket{}
+		buckets[key] = b
+	}
+	return b
+}
+
+// sweep forgets the buckets that refilled completely, which behave like new
+// ones, and the strikes that are out of the window.
+func (l *rateLimiter) sweep(config Config, now time.Time) {
+	for key, b := range l.conns {
+		if limit := config.ConnRateLimits[key.op]; now.Sub(b.updated) >= limit.Per {
+			delete(l.conns, key)
+		}
+	}
+	for key, b := range l.users {
+		if limit := config.UserRateLimits[key.op]; now.Sub(b.updated) >= limit.Per {
+			delete(l.users, key)
+		}
+	}
+	for client, st := range l.strikes {
+		if now.Sub(st.since) > config.RateLimitWindow {
+			delete(l.strikes, client)
+		}
+	}
+	l.lastSweep = now
+}
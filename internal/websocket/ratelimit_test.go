@@ -0,0 +1,194 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+func TestBucket(t *testing.T) {
+	limit := RateLimit{Count: 2, Per: time.Second}
+	start := time.Now()
+
+	tests := []struct {
+		name     string
+		tokens   float64
+		updated  time.Time
+		now      time.Time
+		wantLeft float64
+		wantWait time.Duration
+	}{
+		{name: "new bucket starts full", now: start, wantLeft: 2},
+		{name: "empty", tokens: 0, updated: start, now: start, wantLeft: 0, wantWait: 500 * time.Millisecond},
+		{name: "half a token", tokens: 0, updated: start, now: start.Add(250 * time.Millisecond), wantLeft: 0.5, wantWait: 250 * time.Millisecond},
+		{name: "one token", tokens: 0, updated: start, now: start.Add(500 * time.Millisecond), wantLeft: 1},
+		{name: "capped at count", tokens: 1, updated: start, now: start.Add(time.Hour), wantLeft: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bucket{tokens: tt.tokens, updated: tt.updated}
+			b.refill(limit, tt.now)
+
+			if b.tokens != tt.wantLeft {
+				t.Errorf("got %v tokens, want %v", b.tokens, tt.wantLeft)
+			}
+			if got := b.wait(limit); got != tt.wantWait {
+				t.Errorf("got a wait of %v, want %v", got, tt.wantWait)
+			}
+			if !b.updated.Equal(tt.now) {
+				t.Errorf("bucket was not marked updated at now")
+			}
+		})
+	}
+}
+
+func newTestClient(userID string) *model.WebSocketUser {
+	return &model.WebSocketUser{ID: userID + "-conn", UserID: userID, Stop: func() {}}
+}
+
+// send is an op sent on connection conn of user.
+type send struct {
+	user string
+	conn int
+}
+
+func TestLimit(t *testing.T) {
+	perMinute := func(count int) RateLimit { return RateLimit{Count: count, Per: time.Minute} }
+
+	tests := []struct {
+		name     string
+		config   Config
+		sends    []send
+		op       model.WSOp
+		wantLast bool
+	}{
+		{
+			name:     "under the connection limit",
+			config:   Config{ConnRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(2)}},
+			sends:    []send{{"a", 0}, {"a", 0}},
+			op:       model.OpTyping,
+			wantLast: true,
+		},
+		{
+			name:     "over the connection limit",
+			config:   Config{ConnRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(2)}},
+			sends:    []send{{"a", 0}, {"a", 0}, {"a", 0}},
+			op:       model.OpTyping,
+			wantLast: false,
+		},
+		{
+			name:     "connections do not share their limit",
+			config:   Config{ConnRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(2)}},
+			sends:    []send{{"a", 0}, {"a", 0}, {"a", 1}},
+			op:       model.OpTyping,
+			wantLast: true,
+		},
+		{
+			name:     "op without a limit",
+			config:   Config{ConnRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(1)}},
+			sends:    []send{{"a", 0}, {"a", 0}, {"a", 0}},
+			op:       model.OpMessageCreate,
+			wantLast: true,
+		},
+		{
+			name:     "zero count disables the limit",
+			config:   Config{ConnRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(0)}},
+			sends:    []send{{"a", 0}, {"a", 0}, {"a", 0}},
+			op:       model.OpTyping,
+			wantLast: true,
+		},
+		{
+			name: "user limit shared by connections",
+			config: Config{
+				ConnRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(2)},
+				UserRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(3)},
+			},
+			sends:    []send{{"a", 0}, {"a", 1}, {"a", 0}, {"a", 1}},
+			op:       model.OpTyping,
+			wantLast: false,
+		},
+		{
+			name:     "users do not share their limit",
+			config:   Config{UserRateLimits: map[model.WSOp]RateLimit{model.OpTyping: perMinute(1)}},
+			sends:    []send{{"a", 0}, {"b", 0}},
+			op:       model.OpTyping,
+			wantLast: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewWebSocketServer(tt.config, nil)
+
+			clients := map[send]*model.WebSocketUser{}
+			var ok bool
+			for _, sent := range tt.sends {
+				if clients[sent] == nil {
+					clients[sent] = newTestClient(sent.user)
+				}
+				_, ok = s.limit(clients[sent], tt.op)
+			}
+
+			if ok != tt.wantLast {
+				t.Errorf("last send allowed = %v, want %v", ok, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestLimitTakesNothingWhenDenied(t *testing.T) {
+	s := NewWebSocketServer(Config{
+		ConnRateLimits: map[model.WSOp]RateLimit{model.OpTyping: {Count: 1, Per: time.Minute}},
+		UserRateLimits: map[model.WSOp]RateLimit{model.OpTyping: {Count: 2, Per: time.Minute}},
+	}, nil)
+	first, second := newTestClient("a"), newTestClient("a")
+
+	if _, ok := s.limit(first, model.OpTyping); !ok {
+		t.Fatal("first send was denied")
+	}
+	wait, ok := s.limit(first, model.OpTyping)
+	if ok || wait <= 0 {
+		t.Fatalf("second send on the same connection = %v, %v, want a wait and false", wait, ok)
+	}
+
+	// The denied send must not have used up the token the user has left.
+	if _, ok := s.limit(second, model.OpTyping); !ok {
+		t.Error("send on another connection was denied")
+	}
+}
+
+func TestLimitStrikes(t *testing.T) {
+	tests := []struct {
+		name       string
+		strikes    int
+		denied     int
+		wantClosed bool
+	}{
+		{name: "below the strikes", strikes: 2, denied: 1, wantClosed: false},
+		{name: "reaching the strikes", strikes: 2, denied: 2, wantClosed: true},
+		{name: "zero strikes never close", strikes: 0, denied: 5, wantClosed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewWebSocketServer(Config{
+				ConnRateLimits:   map[model.WSOp]RateLimit{model.OpTyping: {Count: 1, Per: time.Minute}},
+				RateLimitStrikes: tt.strikes,
+				RateLimitWindow:  time.Minute,
+			}, nil)
+			client := newTestClient("a")
+
+			for range tt.denied + 1 {
+				s.limit(client, model.OpTyping)
+			}
+
+			// MarkClosing fails when the limiter already marked the
+			// connection for closing.
+			if closed := !client.MarkClosing(); closed != tt.wantClosed {
+				t.Errorf("connection closed = %v, want %v", closed, tt.wantClosed)
+			}
+		})
+	}
+}
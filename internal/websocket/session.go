@@ -0,0 +1,176 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// Registration asks the hub to register a client, resuming SessionID from
+// LastSeq when set.
+type Registration struct {
+	Client    *model.WebSocketUser
+	SessionID string
+	LastSeq   int64
+
+	done    chan struct{}
+	resumed bool
+}
+
+// Connect registers a client with the hub, resuming sessionID when it is not
+// empty, and queues the hello frame followed by any replayed events. It
+// returns once the client is registered, or right away if the hub stopped,
+// and reports whether the session was resumed.
+func (s *WebSocketServer) Connect(client *model.WebSocketUser, sessionID string, lastSeq int64) bool {
+	reg := &Registration{
+		Client:    client,
+		SessionID: sessionID,
+		LastSeq:   lastSeq,
+		done:      make(chan struct{}),
+	}
+
+	select {
+	case s.Register <- reg:
+	case <-s.stopped:
+		return false
+	}
+
+	select {
+	case <-reg.done:
+		return reg.resumed
+	case <-s.stopped:
+		return false
+	}
+}
+
+// Session returns the live session sessionID when it is held by userID.
+func (s *WebSocketServer) Session(userID, sessionID string) (*model.WebSocketUser, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for client := range s.Clients[userID] {
+		if client.ID == sessionID {
+			return client, true
+		}
+	}
+	return nil, false
+}
+
+func (s *WebSocketServer) registerClient(reg *Registration) {
+	defer close(reg.done)
+
+	client := reg.Client
+
+	s.mu.Lock()
+	hello := model.WSHelloPayload{HeartbeatInterval: s.heartbeatInterval().Milliseconds()}
+	var replay []*model.WSEnvelope
+	if reg.SessionID != "" {
+		events, ok := s.resumeLocked(client, reg.SessionID, reg.LastSeq)
+		hello.Resumed = ok
+		reg.resumed = ok
+		hello.ResyncRequired = !ok
+		replay = events
+	}
+	hello.SessionID = client.ID
+
+	s.addClientLocked(client)
+	s.refreshPresenceLocked(client.UserID)
+	s.mu.Unlock()
+
+	env, err := model.NewWSEnvelope(model.OpHello, "", hello)
+	if err != nil {
+		log.Printf("Error building hello envelope: %v", err)
+		return
+	}
+
+	for _, env := range append([]*model.WSEnvelope{env}, replay...) {
+		if !client.Push(env) {
+			s.overflow(client)
+			return
+		}
+	}
+}
+
+// resumeLocked moves the session sessionID, its sequence, replay buffer and
+// subscriptions onto client. A session whose connection is still registered,
+// because the client reconnected before the server noticed the old one died,
+// is taken over. It returns the events the client missed after lastSeq, or
+// false when the session is unknown, expired, owned by someone else or too
+// far behind to replay.
+func (s *WebSocketServer) resumeLocked(client *model.WebSocketUser, sessionID string, lastSeq int64) ([]*model.WSEnvelope, bool) {
+	previous, ok := s.Sessions[sessionID]
+	if !ok {
+		previous = s.takeOverLocked(client.UserID, sessionID)
+	}
+	if previous == nil || previous.UserID != client.UserID {
+		return nil, false
+	}
+
+	events, ok := previous.ReplaySince(lastSeq)
+	if !ok {
+		s.dropSessionLocked(previous)
+		return nil, false
+	}
+
+	delete(s.Sessions, sessionID)
+	client.ID = sessionID
+	client.Inherit(previous)
+
+	for topic, serverID := range previous.Subscriptions {
+		s.removeFromTopicLocked(previous, topic)
+		s.addToTopicLocked(client, topic, serverID)
+	}
+
+	return events, true
+}
+
+func (s *WebSocketServer) takeOverLocked(userID, sessionID string) *model.WebSocketUser {
+	for client := range s.Clients[userID] {
+		if client.ID != sessionID {
+			continue
+		}
+
+		s.removeClientLocked(client)
+		client.Detach()
+		go client.Close(websocket.StatusNormalClosure, "session resumed on another connection")
+		return client
+	}
+	return nil
+}
+
+// detachLocked keeps a disconnected client subscribed for ResumeWindow so it
+// can be resumed, and schedules its expiry.
+func (s *WebSocketServer) detachLocked(client *model.WebSocketUser) {
+	client.Detach()
+	s.Sessions[client.ID] = client
+
+	time.AfterFunc(s.config.ResumeWindow, func() {
+		select {
+		case s.expire <- client:
+		case <-s.stopped:
+		}
+	})
+}
+
+func (s *WebSocketServer) expireSession(client *model.WebSocketUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Sessions[client.ID] == client {
+		s.dropSessionLocked(client)
+		s.refreshPresenceLocked(client.UserID)
+	}
+}
+
+// dropSessionLocked removes every subscription of a session that will not be
+// resumed.
+func (s *WebSocketServer) dropSessionLocked(client *model.WebSocketUser) {
+	for topic := range client.Subscriptions {
+		s.removeFromTopicLocked(client, topic)
+	}
+	if s.Sessions[client.ID] == client {
+		delete(s.Sessions, client.ID)
+	}
+}
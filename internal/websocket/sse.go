@@ -0,0 +1,108 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+// NewStreamClient builds a session delivered over Server-Sent Events rather
+// than a WebSocket connection. stop ends its stream.
+func (s *WebSocketServer) NewStreamClient(id, userID string, stop context.CancelFunc) *model.WebSocketUser {
+	client := s.NewClient(id, userID, nil)
+	client.Stop = stop
+
+	return client
+}
+
+// Stream is the write pump of a Server-Sent Events session. It writes every
+// queued envelope to w as the data of an event until ctx is done or a write
+// fails. The hello and dispatch events carry an id made of the session id and
+// the last dispatch seq sent, lastSeq to begin with, so a client reconnecting
+// with Last-Event-ID resumes the session. A comment is sent every
+// PingInterval to keep proxies from timing the stream out.
+func (s *WebSocketServer) Stream(ctx context.Context, client *model.WebSocketUser, w http.ResponseWriter, lastSeq int64) error {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := s.flushStream(rc, nil); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.autoIdle(client)
+
+			if err := s.flushStream(rc, func() error {
+				_, err := fmt.Fprint(w, ": keepalive\n\n")
+				return err
+			}); err != nil {
+				return err
+			}
+		case env := <-client.Send:
+			if env.Op == model.OpDispatch {
+				lastSeq = env.Seq
+			}
+
+			if err := s.flushStream(rc, func() error {
+				return writeEvent(w, client.ID, lastSeq, env)
+			}); err != nil {
+				return err
+			}
+			s.stats.sent.Add(1)
+		}
+	}
+}
+
+// flushStream runs write and flushes it within WriteTimeout. The deadline is
+// lifted again afterwards, the stream itself has no end.
+func (s *WebSocketServer) flushStream(rc *http.ResponseController, write func() error) error {
+	if err := rc.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	if write != nil {
+		if err := write(); err != nil {
+			return err
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+func writeEvent(w http.ResponseWriter, sessionID string, lastSeq int64, env *model.WSEnvelope) error {
+	frame, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	if env.Op == model.OpHello || env.Op == model.OpDispatch {
+		if _, err := fmt.Fprintf(w, "id: %s:%d\n", sessionID, lastSeq); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "data: %s\n\n", frame)
+	return err
+}
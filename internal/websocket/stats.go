@@ -0,0 +1,38 @@
+package websocket
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+type stats struct {
+	sent          atomic.Int64
+	dropped       atomic.Int64
+	slowConsumers atomic.Int64
+	rateLimited   atomic.Int64
+}
+
+// Stats returns a map of hub counters in the same shape as the database
+// health report.
+func (s *WebSocketServer) Stats() map[string]string {
+	s.mu.RLock()
+	users := len(s.Clients)
+	connections := 0
+	for _, conns := range s.Clients {
+		connections += len(conns)
+	}
+	sessions := len(s.Sessions)
+	topics := len(s.Topics)
+	s.mu.RUnlock()
+
+	return map[string]string{
+		"users":          strconv.Itoa(users),
+		"connections":    strconv.Itoa(connections),
+		"sessions":       strconv.Itoa(sessions),
+		"topics":         strconv.Itoa(topics),
+		"sent":           strconv.FormatInt(s.stats.sent.Load(), 10),
+		"dropped":        strconv.FormatInt(s.stats.dropped.Load(), 10),
+		"slow_consumers": strconv.FormatInt(s.stats.slowConsumers.Load(), 10),
+		"rate_limited":   strconv.FormatInt(s.stats.rateLimited.Load(), 10),
+	}
+}
@@ -0,0 +1,24 @@
+package websocket
+
+import "github.com/razaq-himawan/chat-app-api/internal/app/model"
+
+func ChannelTopic(channelID string) string {
+	return "channel:" + channelID
+}
+
+func ConversationTopic(conversationID string) string {
+	return "conversation:" + conversationID
+}
+
+// TopicFor resolves the topic targeted by a payload that carries either a
+// channel id or a conversation id.
+func TopicFor(channelID, conversationID string) (string, error) {
+	if (channelID == "") == (conversationID == "") {
+		return "", NewOpError(model.ErrCodeBadRequest, "exactly one of channel_id or conversation_id is required")
+	}
+
+	if channelID != "" {
+		return ChannelTopic(channelID), nil
+	}
+	return ConversationTopic(conversationID), nil
+}
@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+)
+
+type typingKey struct {
+	userID string
+	topic  string
+}
+
+type typingEntry struct {
+	payload  model.WSTypingPayload
+	lastSent time.Time
+	timer    *time.Timer
+}
+
+// typingTracker remembers who is typing where, entirely in memory. Entries
+// are dropped by their expiry timer or when the user sends a message.
+type typingTracker struct {
+	mu      sync.Mutex
+	entries map[typingKey]*typingEntry
+}
+
+func newTypingTracker() *typingTracker {
+	return &typingTracker{entries: make(map[typingKey]*typingEntry)}
+}
+
+// StartTyping tells the other subscribers of topic that the client's user is
+// typing. Refreshes within TypingThrottle only push back the expiry, and
+// typing_stop is sent once no refresh came for TypingTimeout. The client must
+// be subscribed to topic.
+func (s *WebSocketServer) StartTyping(client *model.WebSocketUser, topic string, payload model.WSTypingPayload) error {
+	if !s.IsSubscribed(client, topic) {
+		return NewOpError(model.ErrCodeForbidden, "not subscribed to %s", topic)
+	}
+
+	payload.UserID = client.UserID
+	payload.Timeout = s.config.TypingTimeout.Milliseconds()
+	key := typingKey{userID: client.UserID, topic: topic}
+
+	s.typing.mu.Lock()
+	entry, ok := s.typing.entries[key]
+	if !ok {
+		entry = &typingEntry{payload: payload}
+		s.typing.entries[key] = entry
+		entry.timer = time.AfterFunc(s.config.TypingTimeout, func() {
+			s.expireTyping(key, entry)
+		})
+	} else {
+		entry.timer.Reset(s.config.TypingTimeout)
+	}
+
+	throttled := time.Since(entry.lastSent) < s.config.TypingThrottle
+	if !throttled {
+		entry.lastSent = time.Now()
+	}
+	s.typing.mu.Unlock()
+
+	if throttled {
+		return nil
+	}
+	return s.publishExcept(topic, client.UserID, model.EventTypingStart, payload)
+}
+
+// StopTyping clears the typing state of userID in topic, typically because
+// the message they were typing was sent.
+func (s *WebSocketServer) StopTyping(userID, topic string) error {
+	key := typingKey{userID: userID, topic: topic}
+
+	s.typing.mu.Lock()
+	entry, ok := s.typing.entries[key]
+	if ok {
+		entry.timer.Stop()
+		delete(s.typing.entries, key)
+	}
+	s.typing.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return s.publishExcept(topic, userID, model.EventTypingStop, entry.payload)
+}
+
+func (s *WebSocketServer) expireTyping(key typingKey, entry *typingEntry) {
+	s.typing.mu.Lock()
+	current, ok := s.typing.entries[key]
+	if ok && current == entry {
+		delete(s.typing.entries, key)
+	}
+	s.typing.mu.Unlock()
+
+	if ok && current == entry {
+		s.publishExcept(key.topic, key.userID, model.EventTypingStop, entry.payload)
+	}
+}
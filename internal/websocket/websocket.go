@@ -1,163 +1,484 @@
-package websocket
-
-import (
-	"context"
-	"log"
-	"sync"
-
-	"github.com/coder/websocket"
-	"github.com/razaq-himawan/chat-app-api/internal/app/model"
-)
-
-type WebSocketServer struct {
-	DmClients      map[string]*model.WebSocketUser
-	ChannelClients map[string]*model.WebSocketUser
-	Broadcast      chan *model.Message
-	Register       chan *model.WebSocketUser
-	Unregister     chan *model.WebSocketUser
-	mu             sync.RWMutex
-}
-
-var wsServer *WebSocketServer
-var once sync.Once
-
-func NewWebSocketServer() *WebSocketServer {
-	return &WebSocketServer{
-		DmClients:      make(map[string]*model.WebSocketUser),
-		ChannelClients: make(map[string]*model.WebSocketUser),
-		Broadcast:      make(chan *model.Message, 100),
-		Register:       make(chan *model.WebSocketUser, 100),
-		Unregister:     make(chan *model.WebSocketUser, 100),
-	}
-}
-
-func (s *WebSocketServer) Start(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("WebSocket server shutting down...")
-			s.disconnectAllClients()
-			return
-		case client := <-s.Register:
-			s.registerClient(client)
-
-		case client := <-s.Unregister:
-			s.unregisterClient(client)
-
-		case message := <-s.Broadcast:
-			s.handleBroadcastMessage(ctx, message)
-		}
-	}
-}
-
-func (s *WebSocketServer) registerClient(client *model.WebSocketUser) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if client.Type != model.DM && client.Type != model.CHANNEL {
-		log.Printf("Invalid client type: %s", client.Type)
-		return
-	}
-
-	client.IsOnline = true
-	if client.Type == model.DM {
-		s.DmClients[client.UserID] = client
-	} else if client.Type == model.CHANNEL {
-		s.ChannelClients[client.UserID] = client
-	}
-}
-
-func (s *WebSocketServer) unregisterClient(client *model.WebSocketUser) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if client.Type == model.DM {
-		delete(s.DmClients, client.UserID)
-	} else if client.Type == model.CHANNEL {
-		delete(s.ChannelClients, client.UserID)
-	}
-	client.IsOnline = false
-}
-
-func (server *WebSocketServer) handleBroadcastMessage(ctx context.Context, message *model.Message) {
-	if message.ConversationID != "" {
-		server.SendMessageToConversation(ctx, message.ConversationID, message.Content)
-	} else if message.ChannelID != "" {
-		server.SendMessageToChannel(ctx, message.ChannelID, message.Content)
-	} else {
-		log.Println("Invalid message: neither ConversationID nor ChannelID provided")
-	}
-}
-
-func (server *WebSocketServer) SendMessageToConversation(ctx context.Context, conversationID, message string) {
-	server.mu.RLock()
-	defer server.mu.RUnlock()
-
-	for _, client := range server.DmClients {
-		if client.ConversationID == conversationID && client.IsOnline {
-			if err := client.Conn.Write(ctx, websocket.MessageText, []byte(message)); err != nil {
-				server.handleConnectionError(client, client.UserID)
-			}
-		}
-	}
-}
-
-func (server *WebSocketServer) SendMessageToChannel(ctx context.Context, channelID, message string) {
-	server.mu.RLock()
-	defer server.mu.RUnlock()
-
-	for _, client := range server.ChannelClients {
-		if client.ChannelID == channelID && client.IsOnline {
-			if err := client.Conn.Write(ctx, websocket.MessageText, []byte(message)); err != nil {
-				server.handleConnectionError(client, client.UserID)
-			}
-		}
-	}
-}
-
-func (server *WebSocketServer) handleConnectionError(client *model.WebSocketUser, userID string) {
-	log.Printf("User %s is offline, removing from active clients", userID)
-
-	client.IsOnline = false
-	safeClose(client.Conn, userID)
-
-	server.mu.Lock()
-	defer server.mu.Unlock()
-
-	if client.Type == model.DM {
-		delete(server.DmClients, userID)
-	} else if client.Type == model.CHANNEL {
-		delete(server.ChannelClients, userID)
-	}
-}
-
-func (s *WebSocketServer) disconnectAllClients() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	for userID, client := range s.DmClients {
-		safeClose(client.Conn, userID)
-		delete(s.DmClients, userID)
-	}
-	for userID, client := range s.ChannelClients {
-		safeClose(client.Conn, userID)
-		delete(s.ChannelClients, userID)
-	}
-	log.Println("All clients disconnected.")
-}
-
-func safeClose(conn *websocket.Conn, userID string) {
-	if conn == nil {
-		return
-	}
-	if err := conn.Close(websocket.StatusNormalClosure, "closing connection due to error"); err != nil {
-		log.Printf("Error closing WebSocket connection for user %s: %v", userID, err)
-	}
-}
-
-func GetWebSocketServer() *WebSocketServer {
-	once.Do(func() {
-		wsServer = NewWebSocketServer()
-	})
-	return wsServer
-}
+package websocket
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/razaq-himawan/chat-app-api/internal/app/model"
+	"github.com/razaq-himawan/chat-app-api/utils"
+)
+
+type EventKind string
+
+const (
+	// KindDispatch delivers Envelope to every session subscribed to Topic,
+	// minus those held by ExcludeUserID, or, when UserIDs is set, to every
+	// session held by those users.
+	KindDispatch EventKind = "dispatch"
+	// KindRevoke removes the sessions of UserID from Topic, or from every
+	// topic granted by ServerID.
+	KindRevoke EventKind = "revoke"
+	// KindPresence reports the status derived from the sessions UserID holds
+	// on the instance NodeID.
+	KindPresence EventKind = "presence"
+)
+
+// Event is what the hub exchanges with the other instances through PubSub.
+type Event struct {
+	Kind          EventKind           `json:"kind"`
+	Topic         string              `json:"topic,omitempty"`
+	UserID        string              `json:"user_id,omitempty"`
+	UserIDs       []string            `json:"user_ids,omitempty"`
+	ExcludeUserID string              `json:"exclude_user_id,omitempty"`
+	ServerID      string              `json:"server_id,omitempty"`
+	NodeID        string              `json:"node_id,omitempty"`
+	Presence      model.ProfileStatus `json:"presence,omitempty"`
+	Envelope      *model.WSEnvelope   `json:"envelope,omitempty"`
+}
+
+type connSet map[*model.WebSocketUser]struct{}
+
+type WebSocketServer struct {
+	// Clients indexes live connections by user id, Sessions indexes the
+	// detached ones waiting to be resumed by session id, and Topics indexes
+	// both by the channel or conversation topic they subscribed to.
+	Clients    map[string]connSet
+	Sessions   map[string]*model.WebSocketUser
+	Topics     map[string]connSet
+	Broadcast  chan *Event
+	Register   chan *Registration
+	Unregister chan *model.WebSocketUser
+	expire     chan *model.WebSocketUser
+	handlers   map[model.WSOp]OpHandlerFunc
+	pubsub     PubSub
+	nodeID     string
+	config     Config
+	stats      stats
+	presence   *presenceTracker
+	typing     *typingTracker
+	nonces     *nonceCache
+	limiter    *rateLimiter
+	mu         sync.RWMutex
+
+	quit     chan struct{}
+	stopped  chan struct{}
+	quitOnce sync.Once
+}
+
+// NewWebSocketServer builds a hub that exchanges its events with the other
+// instances through pubsub.
+func NewWebSocketServer(config Config, pubsub PubSub) *WebSocketServer {
+	s := &WebSocketServer{
+		Clients:    make(map[string]connSet),
+		Sessions:   make(map[string]*model.WebSocketUser),
+		Topics:     make(map[string]connSet),
+		Broadcast:  make(chan *Event, 100),
+		Register:   make(chan *Registration, 100),
+		Unregister: make(chan *model.WebSocketUser, 100),
+		expire:     make(chan *model.WebSocketUser, 100),
+		handlers:   make(map[model.WSOp]OpHandlerFunc),
+		pubsub:     pubsub,
+		nodeID:     utils.RandomID(),
+		config:     config,
+		presence:   newPresenceTracker(),
+		typing:     newTypingTracker(),
+		nonces:     newNonceCache(),
+		limiter:    newRateLimiter(),
+		quit:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	s.handlers[model.OpHeartbeat] = s.handleHeartbeat
+	s.handlers[model.OpPresence] = s.handlePresence
+
+	return s
+}
+
+// Clustered reports whether other instances share the events of the hub, in
+// which case none of them knows about every connection.
+func (s *WebSocketServer) Clustered() bool {
+	return s.config.PubSub != PubSubMemory
+}
+
+// Start runs the hub loop. It must be called exactly once, with a context
+// tied to the process rather than to a request, and returns after ctx is done
+// or Shutdown is called and every client has been sent a close frame.
+func (s *WebSocketServer) Start(ctx context.Context) {
+	defer close(s.stopped)
+
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+
+	go s.listen(listenCtx)
+	go s.presence.run(s.stopped, s.announcePresence)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.drain()
+			return
+		case <-s.quit:
+			s.drain()
+			return
+		case reg := <-s.Register:
+			s.registerClient(reg)
+
+		case client := <-s.Unregister:
+			s.unregisterClient(client)
+
+		case client := <-s.expire:
+			s.expireSession(client)
+
+		case event := <-s.Broadcast:
+			s.handleEvent(event)
+		}
+	}
+}
+
+// listen feeds the events of every instance into the hub loop, subscribing
+// again whenever the subscription breaks.
+func (s *WebSocketServer) listen(ctx context.Context) {
+	for {
+		err := s.pubsub.Subscribe(ctx, s.receive)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("WebSocket pub/sub subscription lost, retrying: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *WebSocketServer) receive(event *Event) {
+	select {
+	case s.Broadcast <- event:
+	case <-s.stopped:
+	}
+}
+
+func (s *WebSocketServer) handleEvent(event *Event) {
+	switch event.Kind {
+	case KindDispatch:
+		event.Envelope = event.Envelope.Shared()
+		if len(event.UserIDs) > 0 {
+			s.sendToUsers(event.UserIDs, event.Envelope)
+		} else {
+			s.sendToTopic(event.Topic, event.ExcludeUserID, event.Envelope)
+		}
+	case KindRevoke:
+		if event.ServerID != "" {
+			s.revokeServer(event.UserID, event.ServerID)
+		} else {
+			s.revokeTopic(event.UserID, event.Topic)
+		}
+	case KindPresence:
+		if event.NodeID != s.nodeID {
+			s.presence.setRemote(event.NodeID, event.UserID, event.Presence)
+		}
+	}
+}
+
+func (s *WebSocketServer) addClientLocked(client *model.WebSocketUser) {
+	conns, ok := s.Clients[client.UserID]
+	if !ok {
+		conns = make(connSet)
+		s.Clients[client.UserID] = conns
+	}
+	conns[client] = struct{}{}
+	client.IsOnline = true
+}
+
+// unregisterClient takes a client off the live connections. Its session is
+// kept for ResumeWindow, or dropped right away when resuming is disabled.
+func (s *WebSocketServer) unregisterClient(client *model.WebSocketUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Clients[client.UserID][client]; !ok {
+		// Already taken over by a resuming connection.
+		return
+	}
+	s.removeClientLocked(client)
+
+	if s.config.ResumeWindow > 0 {
+		s.detachLocked(client)
+	} else {
+		s.dropSessionLocked(client)
+	}
+	s.refreshPresenceLocked(client.UserID)
+}
+
+func (s *WebSocketServer) removeClientLocked(client *model.WebSocketUser) {
+	if conns, ok := s.Clients[client.UserID]; ok {
+		delete(conns, client)
+		if len(conns) == 0 {
+			delete(s.Clients, client.UserID)
+		}
+	}
+	client.IsOnline = false
+}
+
+func (s *WebSocketServer) removeFromTopicLocked(client *model.WebSocketUser, topic string) {
+	delete(client.Subscriptions, topic)
+
+	if conns, ok := s.Topics[topic]; ok {
+		delete(conns, client)
+		if len(conns) == 0 {
+			delete(s.Topics, topic)
+		}
+	}
+}
+
+// Subscribe adds the client to topic. serverID records which server grants
+// access to the topic so that losing membership can revoke it.
+func (s *WebSocketServer) Subscribe(client *model.WebSocketUser, topic, serverID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addToTopicLocked(client, topic, serverID)
+}
+
+func (s *WebSocketServer) addToTopicLocked(client *model.WebSocketUser, topic, serverID string) {
+	conns, ok := s.Topics[topic]
+	if !ok {
+		conns = make(connSet)
+		s.Topics[topic] = conns
+	}
+	conns[client] = struct{}{}
+	client.Subscriptions[topic] = serverID
+}
+
+func (s *WebSocketServer) IsSubscribed(client *model.WebSocketUser, topic string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := client.Subscriptions[topic]
+	return ok
+}
+
+func (s *WebSocketServer) Unsubscribe(client *model.WebSocketUser, topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeFromTopicLocked(client, topic)
+}
+
+// Publish queues a dispatch event for every connection subscribed to topic.
+func (s *WebSocketServer) Publish(topic string, eventType model.WSEventType, data any) error {
+	env, err := model.NewWSEnvelope(model.OpDispatch, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	return s.send(&Event{Kind: KindDispatch, Topic: topic, Envelope: env})
+}
+
+// publishExcept is Publish leaving out the connections of excludeUserID,
+// used for events about that user's own activity.
+func (s *WebSocketServer) publishExcept(topic, excludeUserID string, eventType model.WSEventType, data any) error {
+	env, err := model.NewWSEnvelope(model.OpDispatch, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	return s.send(&Event{Kind: KindDispatch, Topic: topic, ExcludeUserID: excludeUserID, Envelope: env})
+}
+
+// PublishToUser queues a dispatch event for every connection held by userID,
+// regardless of its subscriptions.
+func (s *WebSocketServer) PublishToUser(userID string, eventType model.WSEventType, data any) error {
+	return s.PublishToUsers([]string{userID}, eventType, data)
+}
+
+// PublishToUsers is PublishToUser for several users at once, sent to the
+// other instances as a single event.
+func (s *WebSocketServer) PublishToUsers(userIDs []string, eventType model.WSEventType, data any) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	env, err := model.NewWSEnvelope(model.OpDispatch, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	return s.send(&Event{Kind: KindDispatch, UserIDs: userIDs, Envelope: env})
+}
+
+// Disconnect unregisters a client from the hub. It does not block once the
+// hub has stopped.
+func (s *WebSocketServer) Disconnect(client *model.WebSocketUser) {
+	select {
+	case s.Unregister <- client:
+	case <-s.stopped:
+	}
+}
+
+func (s *WebSocketServer) send(event *Event) error {
+	return s.pubsub.Publish(context.Background(), event)
+}
+
+// Shutdown stops the hub loop and waits until every client was told to
+// reconnect, or until ctx expires.
+func (s *WebSocketServer) Shutdown(ctx context.Context) error {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+	})
+
+	select {
+	case <-s.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// UnsubscribeUser removes every session held by userID, on every instance,
+// from topic, used when the user loses access to it.
+func (s *WebSocketServer) UnsubscribeUser(userID, topic string) error {
+	return s.send(&Event{Kind: KindRevoke, UserID: userID, Topic: topic})
+}
+
+// UnsubscribeUserFromServer removes every session held by userID, on every
+// instance, from all topics granted by serverID.
+func (s *WebSocketServer) UnsubscribeUserFromServer(userID, serverID string) error {
+	return s.send(&Event{Kind: KindRevoke, UserID: userID, ServerID: serverID})
+}
+
+func (s *WebSocketServer) revokeTopic(userID, topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, client := range s.sessionsOfLocked(userID) {
+		s.removeFromTopicLocked(client, topic)
+	}
+}
+
+func (s *WebSocketServer) revokeServer(userID, serverID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, client := range s.sessionsOfLocked(userID) {
+		for topic, grantedBy := range client.Subscriptions {
+			if grantedBy == serverID {
+				s.removeFromTopicLocked(client, topic)
+			}
+		}
+	}
+}
+
+// SendToTopic queues an envelope for every connection subscribed to topic.
+func (s *WebSocketServer) SendToTopic(topic string, env *model.WSEnvelope) {
+	s.sendToTopic(topic, "", env)
+}
+
+func (s *WebSocketServer) sendToTopic(topic, excludeUserID string, env *model.WSEnvelope) {
+	s.mu.RLock()
+	recipients := make([]*model.WebSocketUser, 0, len(s.Topics[topic]))
+	for client := range s.Topics[topic] {
+		if client.UserID != excludeUserID {
+			recipients = append(recipients, client)
+		}
+	}
+	s.mu.RUnlock()
+
+	s.sendToAll(recipients, env)
+}
+
+// SendToUser queues an envelope for every session held by a user, detached
+// ones included so they can replay it.
+func (s *WebSocketServer) SendToUser(userID string, env *model.WSEnvelope) {
+	s.sendToUsers([]string{userID}, env)
+}
+
+// sendToUsers is SendToUser for several users, each served once even when
+// listed twice.
+func (s *WebSocketServer) sendToUsers(userIDs []string, env *model.WSEnvelope) {
+	users := make(map[string]struct{}, len(userIDs))
+	for _, userID := range userIDs {
+		users[userID] = struct{}{}
+	}
+
+	var recipients []*model.WebSocketUser
+
+	s.mu.RLock()
+	for userID := range users {
+		for client := range s.Clients[userID] {
+			recipients = append(recipients, client)
+		}
+	}
+	for _, client := range s.Sessions {
+		if _, ok := users[client.UserID]; ok {
+			recipients = append(recipients, client)
+		}
+	}
+	s.mu.RUnlock()
+
+	s.sendToAll(recipients, env)
+}
+
+// sessionsOfLocked returns the live and detached sessions of userID.
+func (s *WebSocketServer) sessionsOfLocked(userID string) []*model.WebSocketUser {
+	sessions := make([]*model.WebSocketUser, 0, len(s.Clients[userID]))
+	for client := range s.Clients[userID] {
+		sessions = append(sessions, client)
+	}
+	for _, client := range s.Sessions {
+		if client.UserID == userID {
+			sessions = append(sessions, client)
+		}
+	}
+	return sessions
+}
+
+func (s *WebSocketServer) sendToAll(recipients []*model.WebSocketUser, env *model.WSEnvelope) {
+	for _, client := range recipients {
+		s.enqueue(client, env)
+	}
+}
+
+// SendToClient queues an envelope for a single client, outside of any topic.
+func (s *WebSocketServer) SendToClient(client *model.WebSocketUser, env *model.WSEnvelope) {
+	s.enqueue(client, env)
+}
+
+// drain closes every client with StatusServiceRestart so they reconnect to
+// another instance, or to this one once it is back.
+func (s *WebSocketServer) drain() {
+	log.Println("WebSocket server shutting down...")
+
+	s.mu.Lock()
+	clients := []*model.WebSocketUser{}
+	for _, conns := range s.Clients {
+		for client := range conns {
+			clients = append(clients, client)
+			s.removeClientLocked(client)
+			s.dropSessionLocked(client)
+		}
+	}
+	for _, client := range s.Sessions {
+		s.dropSessionLocked(client)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *model.WebSocketUser) {
+			defer wg.Done()
+			if err := client.Close(websocket.StatusServiceRestart, "server restarting, please reconnect"); err != nil {
+				log.Printf("Error closing connection for user %s: %v", client.UserID, err)
+			}
+		}(client)
+	}
+	wg.Wait()
+
+	log.Println("All clients disconnected.")
+}
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS messages(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    content TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    member_id UUID,
+    channel_id UUID,
+    conversation_id UUID,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (member_id) REFERENCES members (id) ON DELETE SET NULL,
+    FOREIGN KEY (channel_id) REFERENCES channels (id) ON DELETE CASCADE,
+    CHECK ((channel_id IS NULL) <> (conversation_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS messages_channel_id_idx ON messages (channel_id, created_at, id);
+CREATE INDEX IF NOT EXISTS messages_conversation_id_idx ON messages (conversation_id, created_at, id);
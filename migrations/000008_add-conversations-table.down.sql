@@ -0,0 +1,3 @@
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_conversation_id_fkey;
+
+DROP TABLE IF EXISTS conversations;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS conversations(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    member_one_id UUID NOT NULL,
+    member_two_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (member_one_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (member_two_id) REFERENCES users (id) ON DELETE CASCADE,
+    UNIQUE (member_one_id, member_two_id),
+    CHECK (member_one_id < member_two_id)
+);
+
+CREATE INDEX IF NOT EXISTS conversations_member_two_id_idx ON conversations (member_two_id);
+
+ALTER TABLE messages
+    ADD CONSTRAINT messages_conversation_id_fkey
+    FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE;
@@ -0,0 +1,18 @@
+ALTER TABLE messages DROP COLUMN IF EXISTS type;
+
+DROP TYPE IF EXISTS MESSAGETYPE;
+
+DROP TABLE IF EXISTS conversation_participants;
+
+DELETE FROM conversations WHERE type = 'GROUP';
+
+ALTER TABLE conversations
+    DROP CONSTRAINT IF EXISTS conversations_members_check,
+    ALTER COLUMN member_one_id SET NOT NULL,
+    ALTER COLUMN member_two_id SET NOT NULL,
+    ADD CONSTRAINT conversations_check CHECK (member_one_id < member_two_id),
+    DROP COLUMN IF EXISTS owner_id,
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS type;
+
+DROP TYPE IF EXISTS CONVERSATIONTYPE;
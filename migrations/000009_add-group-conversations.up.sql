@@ -0,0 +1,29 @@
+CREATE TYPE CONVERSATIONTYPE AS ENUM ('DM', 'GROUP');
+
+ALTER TABLE conversations
+    ADD COLUMN type CONVERSATIONTYPE NOT NULL DEFAULT 'DM',
+    ADD COLUMN name VARCHAR(100),
+    ADD COLUMN owner_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    ALTER COLUMN member_one_id DROP NOT NULL,
+    ALTER COLUMN member_two_id DROP NOT NULL,
+    DROP CONSTRAINT IF EXISTS conversations_check,
+    ADD CONSTRAINT conversations_members_check CHECK (
+        (type = 'DM' AND member_one_id < member_two_id)
+        OR (type = 'GROUP' AND member_one_id IS NULL AND member_two_id IS NULL)
+    );
+
+CREATE TABLE IF NOT EXISTS conversation_participants(
+    conversation_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (conversation_id, user_id),
+    FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS conversation_participants_user_id_idx ON conversation_participants (user_id);
+
+CREATE TYPE MESSAGETYPE AS ENUM ('DEFAULT', 'SYSTEM');
+
+ALTER TABLE messages ADD COLUMN type MESSAGETYPE NOT NULL DEFAULT 'DEFAULT';
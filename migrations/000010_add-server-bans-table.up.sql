@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS server_bans(
+    server_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    banned_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (server_id, user_id),
+    FOREIGN KEY (server_id) REFERENCES servers (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (banned_by) REFERENCES users (id) ON DELETE SET NULL
+);
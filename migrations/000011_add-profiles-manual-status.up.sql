@@ -0,0 +1 @@
+ALTER TABLE profiles ADD COLUMN manual_status PROFILESTATUS;
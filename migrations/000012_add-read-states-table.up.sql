@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS read_states(
+    user_id UUID NOT NULL,
+    channel_id UUID,
+    conversation_id UUID,
+    last_message_id UUID NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (channel_id) REFERENCES channels (id) ON DELETE CASCADE,
+    FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE,
+    FOREIGN KEY (last_message_id) REFERENCES messages (id) ON DELETE CASCADE,
+    CHECK ((channel_id IS NULL) <> (conversation_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS read_states_user_channel_idx ON read_states (user_id, channel_id) WHERE channel_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS read_states_user_conversation_idx ON read_states (user_id, conversation_id) WHERE conversation_id IS NOT NULL;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS hub_events(
+    id BIGSERIAL PRIMARY KEY,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS hub_events_created_at_idx ON hub_events (created_at);
@@ -0,0 +1 @@
+ALTER TABLE servers ADD COLUMN icon_url TEXT;
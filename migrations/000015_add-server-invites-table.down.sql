@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS members_user_server_idx;
+
+DROP INDEX IF EXISTS servers_invite_code_text_idx;
+
+DROP TABLE IF EXISTS server_invites;
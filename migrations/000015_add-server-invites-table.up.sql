@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS server_invites(
+    code VARCHAR(36) PRIMARY KEY DEFAULT substr(replace(gen_random_uuid()::text, '-', ''), 1, 10),
+    server_id UUID NOT NULL,
+    creator_id UUID,
+    max_uses INTEGER,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (server_id) REFERENCES servers (id) ON DELETE CASCADE,
+    FOREIGN KEY (creator_id) REFERENCES users (id) ON DELETE SET NULL,
+    CHECK (max_uses IS NULL OR max_uses > 0)
+);
+
+CREATE INDEX IF NOT EXISTS server_invites_server_id_idx ON server_invites (server_id);
+
+-- Default invites are looked up by code as text next to the invites above.
+CREATE INDEX IF NOT EXISTS servers_invite_code_text_idx ON servers ((invite_code::text));
+
+CREATE UNIQUE INDEX IF NOT EXISTS members_user_server_idx ON members (user_id, server_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS channels_server_id_position_idx;
+
+ALTER TABLE channels DROP COLUMN IF EXISTS position;
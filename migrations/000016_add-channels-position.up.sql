@@ -0,0 +1,11 @@
+ALTER TABLE channels ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+UPDATE channels c
+SET position = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY server_id ORDER BY created_at, id) - 1 AS position
+    FROM channels
+) ordered
+WHERE c.id = ordered.id;
+
+CREATE INDEX IF NOT EXISTS channels_server_id_position_idx ON channels (server_id, position);
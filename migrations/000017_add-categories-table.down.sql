@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS channels_category_id_idx;
+
+ALTER TABLE channels DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS categories(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(30) NOT NULL,
+    server_id UUID NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (server_id) REFERENCES servers (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS categories_server_id_position_idx ON categories (server_id, position);
+
+-- Channel positions are counted within their category, channels without one
+-- keep the positions they already have.
+ALTER TABLE channels ADD COLUMN category_id UUID REFERENCES categories (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS channels_category_id_idx ON channels (category_id);
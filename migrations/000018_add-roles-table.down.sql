@@ -0,0 +1,28 @@
+CREATE TYPE ROLETYPE AS ENUM ('ADMIN', 'MODERATOR', 'GUEST');
+
+ALTER TABLE members ADD COLUMN role ROLETYPE NOT NULL DEFAULT 'GUEST';
+
+-- Members keep the highest ROLETYPE their roles allow: kick, ban or manage
+-- messages (448) for MODERATOR, administrator (1) or ownership for ADMIN.
+UPDATE members m
+SET role = 'MODERATOR'
+FROM member_roles mr
+JOIN roles r ON r.id = mr.role_id
+WHERE mr.member_id = m.id AND r.permissions & 448 <> 0;
+
+UPDATE members m
+SET role = 'ADMIN'
+FROM member_roles mr
+JOIN roles r ON r.id = mr.role_id
+WHERE mr.member_id = m.id AND r.permissions & 1 <> 0;
+
+UPDATE members m
+SET role = 'ADMIN'
+FROM servers s
+WHERE s.id = m.server_id AND s.user_id = m.user_id;
+
+ALTER TABLE members ALTER COLUMN role DROP DEFAULT;
+
+DROP TABLE IF EXISTS member_roles;
+
+DROP TABLE IF EXISTS roles;
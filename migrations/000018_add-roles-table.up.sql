@@ -0,0 +1,53 @@
+CREATE TABLE IF NOT EXISTS roles(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    server_id UUID NOT NULL,
+    name VARCHAR(30) NOT NULL,
+    color INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    permissions BIGINT NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (server_id) REFERENCES servers (id) ON DELETE CASCADE
+);
+
+-- The default role is held by every member of its server without being
+-- assigned, there is exactly one per server.
+CREATE UNIQUE INDEX IF NOT EXISTS roles_server_id_default_idx ON roles (server_id) WHERE is_default;
+
+CREATE INDEX IF NOT EXISTS roles_server_id_position_idx ON roles (server_id, position);
+
+CREATE TABLE IF NOT EXISTS member_roles(
+    member_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+
+    PRIMARY KEY (member_id, role_id),
+    FOREIGN KEY (member_id) REFERENCES members (id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS member_roles_role_id_idx ON member_roles (role_id);
+
+-- Every server gets a default role, allowed to create invites (16), plus a
+-- Moderator role, allowed to kick, ban and manage messages (448), and an
+-- Admin role with the administrator permission (1), which take over the
+-- ROLETYPE values of its members.
+INSERT INTO roles (server_id, name, position, permissions, is_default)
+SELECT id, '@everyone', 0, 16, TRUE FROM servers;
+
+INSERT INTO roles (server_id, name, position, permissions)
+SELECT id, 'Moderator', 1, 448 FROM servers;
+
+INSERT INTO roles (server_id, name, position, permissions)
+SELECT id, 'Admin', 2, 1 FROM servers;
+
+INSERT INTO member_roles (member_id, role_id)
+SELECT m.id, r.id
+FROM members m
+JOIN roles r ON r.server_id = m.server_id AND NOT r.is_default
+WHERE (m.role = 'ADMIN' AND r.name = 'Admin') OR (m.role = 'MODERATOR' AND r.name = 'Moderator');
+
+ALTER TABLE members DROP COLUMN role;
+
+DROP TYPE IF EXISTS ROLETYPE;
@@ -0,0 +1,3 @@
+UPDATE roles SET permissions = permissions & ~1024;
+
+DROP TABLE IF EXISTS channel_overwrites;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS channel_overwrites(
+    channel_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    allow BIGINT NOT NULL DEFAULT 0,
+    deny BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (channel_id, role_id),
+    FOREIGN KEY (channel_id) REFERENCES channels (id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS channel_overwrites_role_id_idx ON channel_overwrites (role_id);
+
+-- Channels stay visible to every member: default roles get the new view
+-- channels permission (1024).
+UPDATE roles SET permissions = permissions | 1024 WHERE is_default;
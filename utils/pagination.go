@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// PageParams holds keyset pagination query parameters. At most one of
+// Before, After and Around is set, each being the id of a cursor row.
+type PageParams struct {
+	Limit  int
+	Before string
+	After  string
+	Around string
+}
+
+// Page is the envelope returned by every cursor-paginated endpoint.
+type Page[T any] struct {
+	Data      []T    `json:"data"`
+	Limit     int    `json:"limit"`
+	HasBefore bool   `json:"has_before"`
+	HasAfter  bool   `json:"has_after"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+}
+
+func ParsePageParams(r *http.Request) (PageParams, error) {
+	query := r.URL.Query()
+
+	params := PageParams{
+		Limit:  DefaultPageLimit,
+		Before: query.Get("before"),
+		After:  query.Get("after"),
+		Around: query.Get("around"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxPageLimit {
+			return params, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+		}
+		params.Limit = n
+	}
+
+	cursors := 0
+	for _, c := range []string{params.Before, params.After, params.Around} {
+		if c != "" {
+			cursors++
+		}
+	}
+	if cursors > 1 {
+		return params, fmt.Errorf("only one of before, after or around may be set")
+	}
+
+	return params, nil
+}
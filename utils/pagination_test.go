@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePageParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    PageParams
+		wantErr bool
+	}{
+		{name: "defaults", query: "", want: PageParams{Limit: DefaultPageLimit}},
+		{name: "limit", query: "limit=10", want: PageParams{Limit: 10}},
+		{name: "max limit", query: "limit=100", want: PageParams{Limit: MaxPageLimit}},
+		{name: "before", query: "before=a", want: PageParams{Limit: DefaultPageLimit, Before: "a"}},
+		{name: "after", query: "after=a&limit=5", want: PageParams{Limit: 5, After: "a"}},
+		{name: "around", query: "around=a", want: PageParams{Limit: DefaultPageLimit, Around: "a"}},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "limit too high", query: "limit=101", wantErr: true},
+		{name: "limit not a number", query: "limit=ten", wantErr: true},
+		{name: "two cursors", query: "before=a&after=b", wantErr: true},
+		{name: "three cursors", query: "before=a&after=b&around=c", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/messages?"+tt.query, nil)
+
+			got, err := ParsePageParams(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePageParams(%q) = %+v, want an error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePageParams(%q) failed: %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePageParams(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -37,3 +39,13 @@ func GetTokenFromCookie(r *http.Request) string {
 
 	return cookie.Value
 }
+
+// RandomID returns a random 128 bit hex identifier.
+func RandomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+
+	return hex.EncodeToString(b)
+}